@@ -0,0 +1,111 @@
+package main
+
+import (
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/services/sims_sync"
+	"flag"
+	"fmt"
+	"logger"
+)
+
+// targetConnection находит задачу синхронизации таблицы и подключение к ее целевой БД
+func targetConnection(cfg *config.Config, connections map[string]connectors.DatabaseConnector, l *logger.Log, table string) (config.SyncConfig, connectors.DatabaseConnector, bool) {
+	syncCfg, ok := findTableSync(cfg, table)
+	if !ok {
+		l.Errorf("table %s not found in sync config", table)
+		return syncCfg, nil, false
+	}
+	conn, ok := connections[syncCfg.TargetDB]
+	if !ok {
+		l.Errorf("target DB connection %s not found", syncCfg.TargetDB)
+		return syncCfg, nil, false
+	}
+	return syncCfg, conn, true
+}
+
+// runBackups выводит резервные копии таблицы, начиная с самой новой
+func runBackups(cfg *config.Config, connections map[string]connectors.DatabaseConnector, l *logger.Log, args []string) int {
+	fs := flag.NewFlagSet("backups", flag.ContinueOnError)
+	table := fs.String("table", "", "Таблица цели из секции sync")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if *table == "" {
+		l.Error("backups: -table is required")
+		return exitError
+	}
+
+	syncCfg, conn, ok := targetConnection(cfg, connections, l, *table)
+	if !ok {
+		return exitError
+	}
+	if syncCfg.PublishMode == config.PublishBlueGreen {
+		if active, err := conn.PublishedTable(syncCfg.Target.Table); err == nil && active != "" {
+			fmt.Printf("%s\tpublished\n", active)
+			fmt.Printf("%s\tstandby\n", sims_sync.InactiveTable(syncCfg.Target.Table, active))
+		}
+	}
+	backups, err := connectors.ListBackups(conn, syncCfg.Target.Table)
+	if err != nil {
+		l.Errorf("backups: %v", err)
+		return exitError
+	}
+	if len(backups) == 0 && syncCfg.PublishMode != config.PublishBlueGreen {
+		fmt.Printf("No backups of %s\n", syncCfg.Target.Table)
+		return exitOK
+	}
+	for _, b := range backups {
+		fmt.Printf("%s\t%s\n", b.Table, b.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	return exitOK
+}
+
+// runRollback возвращает резервную копию на место таблицы.
+// Текущая версия таблицы при этом сама сохраняется как резервная копия
+func runRollback(cfg *config.Config, connections map[string]connectors.DatabaseConnector, l *logger.Log, args []string) int {
+	fs := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	table := fs.String("table", "", "Таблица цели из секции sync")
+	backup := fs.String("backup", "", "Имя резервной копии (по умолчанию самая новая)")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if *table == "" {
+		l.Error("rollback: -table is required")
+		return exitError
+	}
+
+	syncCfg, conn, ok := targetConnection(cfg, connections, l, *table)
+	if !ok {
+		return exitError
+	}
+	// В режиме blue_green откат - переключение на вторую физическую таблицу
+	if syncCfg.PublishMode == config.PublishBlueGreen && *backup == "" {
+		active, err := conn.PublishedTable(syncCfg.Target.Table)
+		if err != nil || active == "" {
+			l.Errorf("rollback: %s is not published as view/synonym: %v", syncCfg.Target.Table, err)
+			return exitError
+		}
+		standby := sims_sync.InactiveTable(syncCfg.Target.Table, active)
+		if err := conn.PublishTable(syncCfg.Target.Table, standby); err != nil {
+			l.Errorf("rollback of %s to %s failed: %v", syncCfg.Target.Table, standby, err)
+			return exitError
+		}
+		l.Infof("Table %s rolled back to %s", syncCfg.Target.Table, standby)
+		fmt.Printf("%s now points to %s\n", syncCfg.Target.Table, standby)
+		return exitOK
+	}
+
+	b, err := connectors.FindBackup(conn, syncCfg.Target.Table, *backup)
+	if err != nil {
+		l.Errorf("rollback: %v", err)
+		return exitError
+	}
+	if err := conn.SwapTables(syncCfg.Target.Table, b.Table); err != nil {
+		l.Errorf("rollback of %s to %s failed: %v", syncCfg.Target.Table, b.Table, err)
+		return exitError
+	}
+	l.Infof("Table %s rolled back to backup %s", syncCfg.Target.Table, b.Table)
+	fmt.Printf("%s restored from %s\n", syncCfg.Target.Table, b.Table)
+	return exitOK
+}
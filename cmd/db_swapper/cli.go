@@ -0,0 +1,256 @@
+package main
+
+import (
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"flag"
+	"fmt"
+	"logger"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Коды завершения, общие для всех команд
+const (
+	exitOK          = 0 // Команда выполнена
+	exitFailed      = 1 // Синхронизация не удалась или прервана, diff нашел расхождения
+	exitError       = 2 // Неверные аргументы, конфиг или ошибка выполнения команды
+	exitUnavailable = 3 // Нет подключения к БД или API статуса
+	exitForced      = 4 // Синхронизации не остановились, возможны оставшиеся временные таблицы
+)
+
+// defaultConfig - конфиг по умолчанию, если не задан -config и DB_SWAPPER_CONFIG
+const defaultConfig = "prod.yaml"
+
+// connectDatabases подключается к БД из конфига; тесты подменяют подключения
+var connectDatabases = connect
+
+// command - подкоманда командной строки
+type command struct {
+	name    string
+	summary string
+	connect bool // Команде нужны подключения к БД
+	run     func(cfg *config.Config, connections map[string]connectors.DatabaseConnector, l *logger.Log, args []string) int
+}
+
+var commands = []command{
+	{name: "run", summary: "запустить синхронизации по расписанию (по умолчанию)", connect: true, run: runDaemon},
+	{name: "once", summary: "синхронизировать таблицы один раз и завершиться", connect: true, run: runOnce},
+	{name: "validate", summary: "проверить конфиг (-connect - и подключения к БД)", run: runValidate},
+	{name: "plan", summary: "показать задачи, расписание и ближайшие запуски", run: runPlan},
+	{name: "diff", summary: "сравнить таблицы источника и цели", connect: true, run: runDiff},
+	{name: "status", summary: "показать состояние работающего экземпляра через API статуса", run: runStatus},
+	{name: "backups", summary: "показать резервные копии таблицы", connect: true, run: runBackups},
+	{name: "rollback", summary: "вернуть таблицу из резервной копии", connect: true, run: runRollback},
+	{name: "schema", summary: "показать схемы источника и цели и сопоставление колонок", connect: true, run: runSchema},
+}
+
+func main() {
+	os.Exit(cli(os.Args[1:]))
+}
+
+// cli разбирает общие флаги и выполняет подкоманду. Без подкоманды выполняется run
+func cli(args []string) int {
+	fs := flag.NewFlagSet("db_swapper", flag.ContinueOnError)
+	configPath := fs.String("config", envOr("DB_SWAPPER_CONFIG", defaultConfig), "Путь к конфигу")
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+
+	name, cmdArgs := "run", fs.Args()
+	if len(cmdArgs) > 0 {
+		name, cmdArgs = cmdArgs[0], cmdArgs[1:]
+	}
+	if name == "help" {
+		usage(fs)
+		return exitOK
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage(fs)
+		return exitError
+	}
+
+	cfg, err := config.GetConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config %s: %v\n", *configPath, err)
+		return exitError
+	}
+	l, err := logger.NewLogger(cfg.Logger.Target, cfg.Logger.Level, cfg.Logger.Filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logger: %v\n", err)
+		return exitError
+	}
+	l.Info("init logger")
+
+	var connections map[string]connectors.DatabaseConnector
+	if cmd.connect {
+		if connections, err = connectDatabases(cfg, l); err != nil {
+			l.Error(err.Error())
+			fmt.Fprintln(os.Stderr, err)
+			return exitUnavailable
+		}
+	}
+	code := cmd.run(cfg, connections, l, cmdArgs)
+	disconnect(connections, l)
+	return code
+}
+
+func usage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintf(out, "Использование: db_swapper [-config файл] <команда> [флаги]\n\nКоманды:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(out, "\nФлаги команды: db_swapper <команда> -h\n\nОбщие флаги:\n")
+	fs.PrintDefaults()
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// connect подключается ко всем БД из конфига. Каждое подключение оборачивается
+// автоматическим выключателем. При ошибке уже открытые подключения закрываются
+func connect(cfg *config.Config, l *logger.Log) (map[string]connectors.DatabaseConnector, error) {
+	connections := make(map[string]connectors.DatabaseConnector)
+	onBreakerChange := func(name, state string, err error) {
+		if err != nil {
+			l.Errorf("Circuit breaker for %s is %s: %v", name, state, err)
+			return
+		}
+		l.Infof("Circuit breaker for %s is %s", name, state)
+	}
+	open := func(kind string, dbCfg config.DatabaseConfig, conn connectors.DatabaseConnector) error {
+		if err := conn.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to %s %s: %w", kind, dbCfg.Name, err)
+		}
+		if err := conn.Ping(); err != nil {
+			conn.Disconnect()
+			return fmt.Errorf("failed to ping %s %s: %w", kind, dbCfg.Name, err)
+		}
+		connections[dbCfg.Name] = connectors.NewBreaker(dbCfg.Name, conn, dbCfg.CircuitBreaker, onBreakerChange)
+		l.Infof("%s connection %s successful", kind, dbCfg.Name)
+		return nil
+	}
+
+	var err error
+	for _, oracleCfg := range cfg.Oracle {
+		if err = open("Oracle", oracleCfg, connectors.NewOracleConnector(oracleCfg)); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		for _, mariadbCfg := range cfg.MariaDB {
+			if err = open("MariaDB", mariadbCfg, connectors.NewMariaDBConnector(mariadbCfg)); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		disconnect(connections, l)
+		return nil, err
+	}
+	return connections, nil
+}
+
+func disconnect(connections map[string]connectors.DatabaseConnector, l *logger.Log) {
+	for name, conn := range connections {
+		if err := conn.Disconnect(); err != nil {
+			l.Errorf("Failed to disconnect %s: %v", name, err)
+		}
+	}
+}
+
+// breakersOf возвращает выключатели подключений для API статуса
+func breakersOf(connections map[string]connectors.DatabaseConnector) []*connectors.BreakerConnector {
+	var breakers []*connectors.BreakerConnector
+	for _, conn := range connections {
+		if b, ok := conn.(*connectors.BreakerConnector); ok {
+			breakers = append(breakers, b)
+		}
+	}
+	return breakers
+}
+
+// selector отбирает таблицы по флагам -tables и -databases
+type selector struct {
+	tables    string
+	databases string
+}
+
+func (s *selector) register(fs *flag.FlagSet) {
+	fs.StringVar(&s.tables, "tables", "", "Таблицы цели через запятую (по умолчанию все таблицы из секции sync)")
+	fs.StringVar(&s.databases, "databases", "", "Только таблицы, у которых источник или цель - одна из БД (имена через запятую)")
+}
+
+// filter проверяет, что выбранные таблицы и БД есть в конфиге, и возвращает
+// функцию отбора для buildJobs (nil - все таблицы)
+func (s *selector) filter(cfg *config.Config) (func(syncCfg config.SyncConfig, table config.TableSyncConfig) bool, error) {
+	tables, databases := splitList(s.tables), splitList(s.databases)
+	if tables == nil && databases == nil {
+		return nil, nil
+	}
+
+	knownTables, knownDatabases := make(map[string]bool), make(map[string]bool)
+	for _, db := range append(append([]config.DatabaseConfig{}, cfg.Oracle...), cfg.MariaDB...) {
+		knownDatabases[strings.ToLower(db.Name)] = true
+	}
+	for _, syncCfg := range cfg.Sync {
+		for _, table := range syncCfg.Tables {
+			knownTables[strings.ToLower(table.JobName())] = true
+		}
+	}
+	if unknown := missing(tables, knownTables); len(unknown) > 0 {
+		return nil, fmt.Errorf("tables not found in sync config: %s", strings.Join(unknown, ", "))
+	}
+	if unknown := missing(databases, knownDatabases); len(unknown) > 0 {
+		return nil, fmt.Errorf("databases not found in config: %s", strings.Join(unknown, ", "))
+	}
+
+	return func(syncCfg config.SyncConfig, table config.TableSyncConfig) bool {
+		if tables != nil && !tables[strings.ToLower(table.JobName())] {
+			return false
+		}
+		return databases == nil || databases[strings.ToLower(syncCfg.SourceDB)] || databases[strings.ToLower(syncCfg.TargetDB)]
+	}, nil
+}
+
+// splitList разбирает список через запятую без учета регистра. Пустой список - nil
+func splitList(list string) map[string]bool {
+	var items map[string]bool
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			if items == nil {
+				items = make(map[string]bool)
+			}
+			items[item] = true
+		}
+	}
+	return items
+}
+
+func missing(items, known map[string]bool) []string {
+	var unknown []string
+	for item := range items {
+		if !known[item] {
+			unknown = append(unknown, item)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/domain"
+	"errors"
+	"logger"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// stubConnector - подключение без БД: схема источника читается, а создать
+// промежуточную таблицу не удается. Остальные методы паникуют через nil-интерфейс
+type stubConnector struct {
+	connectors.DatabaseConnector
+}
+
+func (c *stubConnector) ExecuteSelectWithSchema(string, ...interface{}) (*domain.TableSchema, error) {
+	return &domain.TableSchema{Columns: []domain.ColumnInfo{{Name: "ID", DataType: "NUMBER"}}}, nil
+}
+
+func (c *stubConnector) CreateTempTable(string, string, *domain.TableSchema) error {
+	return errors.New("ORA-01536: space quota exceeded for tablespace 'USERS'")
+}
+
+func (c *stubConnector) Disconnect() error {
+	return nil
+}
+
+const testConfig = `
+logger:
+  level: error
+  target: console
+oracle:
+  - name: billing
+mariadb:
+  - name: portal
+sync:
+  - source_db: billing
+    target_db: portal
+    source_type: oracle
+    target_type: mariadb
+    batch_size: 100
+    buffer_size: 100
+    temp_table_suffix: _temp
+    sync_interval: 5m
+    tables:
+      - source:
+          table: SIMS
+        target:
+          table: sims
+          columns:
+            - name: id
+              dataType: BIGINT
+`
+
+func TestCLIExitCodes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+
+	tests := []struct {
+		name       string
+		args       []string
+		connectErr error
+		connects   bool // Команда подключается к БД
+		want       int
+	}{
+		{"help", []string{"-config", path, "help"}, nil, false, exitOK},
+		{"plan", []string{"-config", path, "plan"}, nil, false, exitOK},
+		{"failed sync", []string{"-config", path, "once"}, nil, true, exitFailed},
+		{"unknown command", []string{"-config", path, "frobnicate"}, nil, false, exitError},
+		{"unknown flag", []string{"-bogus"}, nil, false, exitError},
+		{"missing config", []string{"-config", missing, "once"}, nil, false, exitError},
+		{"unknown table", []string{"-config", path, "once", "-tables", "nope"}, nil, true, exitError},
+		{"unknown database", []string{"-config", path, "once", "-databases", "nope"}, nil, true, exitError},
+		{"database unavailable", []string{"-config", path, "once"}, errors.New("failed to ping Oracle billing: ORA-12541: TNS:no listener"), true, exitUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connected := false
+			defer func(prev func(*config.Config, *logger.Log) (map[string]connectors.DatabaseConnector, error)) {
+				connectDatabases = prev
+			}(connectDatabases)
+			connectDatabases = func(cfg *config.Config, _ *logger.Log) (map[string]connectors.DatabaseConnector, error) {
+				connected = true
+				if tt.connectErr != nil {
+					return nil, tt.connectErr
+				}
+				return map[string]connectors.DatabaseConnector{
+					"billing": &stubConnector{},
+					"portal":  &stubConnector{},
+				}, nil
+			}
+
+			if got := cli(tt.args); got != tt.want {
+				t.Errorf("cli(%q) = %d, want %d", tt.args, got, tt.want)
+			}
+			if connected != tt.connects {
+				t.Errorf("cli(%q) connected = %t, want %t", tt.args, connected, tt.connects)
+			}
+		})
+	}
+}
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"db_swapper/internal/config"
 	"db_swapper/internal/connectors"
 	"db_swapper/internal/domain"
+	"db_swapper/internal/leader"
+	"db_swapper/internal/scheduler"
 	"db_swapper/internal/services/sims_sync"
+	"flag"
+	"fmt"
 	"logger"
+	"net/http"
 	"os"
 	"os/signal"
-	"sync"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -19,57 +25,187 @@ func initTransformFunction() map[string]func(r domain.Record) domain.Record {
 	listTransformFunctions["transformDataAllImsi"] = TransformForAllImsi
 	return listTransformFunctions
 }
-func main() {
-	cfg, err := config.GetConfig("prod.yaml")
-	if err != nil {
-		panic(err)
+
+// runDaemon выполняет команду run: синхронизирует таблицы по расписанию до сигнала
+// остановки. Возвращает код завершения по итогам остановки
+func runDaemon(cfg *config.Config, connections map[string]connectors.DatabaseConnector, l *logger.Log, args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	var sel selector
+	sel.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitError
 	}
-	l, err := logger.NewLogger(cfg.Logger.Target, cfg.Logger.Level, cfg.Logger.Filename)
+	include, err := sel.filter(cfg)
 	if err != nil {
-		panic(err)
-	}
-
-	l.Info("init logger")
-	// Создаем маппинг соединений по именам из конфига
-	connections := make(map[string]connectors.DatabaseConnector)
-	// Инициализируем Oracle соединения
-	for _, oracleCfg := range cfg.Oracle {
-		conn := connectors.NewOracleConnector(oracleCfg)
-		err := conn.Connect()
-		if err != nil {
-			l.Fatalf("Failed to connect to Oracle %s: %v", oracleCfg.Name, err)
+		l.Errorf("run: %v", err)
+		return exitError
+	}
+
+	// Канал для graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	// SIGUSR1 запускает все независимые задачи вне расписания
+	trigger := make(chan os.Signal, 1)
+	signal.Notify(trigger, syscall.SIGUSR1)
+
+	// Все задачи запускаются центральным планировщиком
+	schedOpts := []scheduler.Option{scheduler.WithMaxConcurrent(cfg.Scheduler.MaxConcurrentSyncs)}
+	for _, db := range append(append([]config.DatabaseConfig{}, cfg.Oracle...), cfg.MariaDB...) {
+		schedOpts = append(schedOpts, scheduler.WithResourceLimit(db.Name, db.MaxConcurrentSyncs))
+	}
+	sched := scheduler.New(l, schedOpts...)
+
+	jobs := buildJobs(cfg, connections, l, include)
+	if err := checkSelectedDependencies(jobs); err != nil {
+		l.Errorf("run: %v", err)
+		return exitError
+	}
+	for _, job := range jobs {
+		if err := sched.Add(job); err != nil {
+			l.Errorf("Failed to schedule job %s: %v", job.Name, err)
+			continue
+		}
+		l.Infof("Scheduled sync for table %s", job.Name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	drained := make(chan struct{}) // Закрывается, когда shutdown дождался синхронизаций
+	// С выбором лидера задачи запускает только экземпляр, удерживающий аренду
+	var elector *leader.Elector
+	if cfg.Leader != nil {
+		leaseConn, ok := connections[cfg.Leader.Database]
+		if !ok {
+			l.Fatalf("Leader DB connection %s not found", cfg.Leader.Database)
+		}
+		elector = leader.New(leaseConn, *cfg.Leader, l)
+		l.Infof("Leader election enabled, instance %s", elector.Instance())
+		lead := func(termCtx context.Context) {
+			sched.Start(termCtx)
+			if ctx.Err() == nil {
+				// Лидерство потеряно: синхронизации прерываются, пока те же таблицы
+				// не начал загружать новый лидер
+				sched.Abort()
+				sched.Wait()
+				return
+			}
+			// Остановка: аренда продлевается, пока shutdown ждет синхронизации
+			<-drained
+		}
+		go func() {
+			defer close(stopped)
+			if err := elector.Run(ctx, lead); err != nil {
+				l.Fatalf("Leader election failed: %v", err)
+			}
+		}()
+	} else {
+		go func() {
+			sched.Start(ctx)
+			close(stopped)
+		}()
+	}
+	var statusServer *http.Server
+	if cfg.Status.Listen != "" {
+		statusServer = serveStatus(cfg.Status, sched, elector, breakersOf(connections), l)
+	}
+	for _, job := range sched.Jobs() {
+		if len(job.DependsOn) > 0 {
+			l.Infof("Job %s runs after %s", job.Name, strings.Join(job.DependsOn, ", "))
+			continue
 		}
-		err = conn.Ping()
-		if err != nil {
-			l.Fatalf("Failed to ping Oracle %s: %v", oracleCfg.Name, err)
+		l.Infof("Job %s next run at %s", job.Name, job.Next.Format(time.RFC3339))
+	}
+
+	l.Info("Application started successfully")
+
+	// Ожидаем сигнала завершения, SIGUSR1 запускает синхронизацию вне расписания
+	for waiting := true; waiting; {
+		select {
+		case <-quit:
+			waiting = false
+		case <-trigger:
+			triggered, err := sched.TriggerAll()
+			if err != nil {
+				l.Errorf("Manual run rejected: %v", err)
+				continue
+			}
+			l.Infof("Manual run requested by signal: %s", strings.Join(triggered, ", "))
 		}
-		connections[oracleCfg.Name] = conn
-		l.Infof("Oracle connection %s successful", oracleCfg.Name)
+	}
+	l.Info("Shutting down: scheduling stopped")
+	cancel()
+	if elector == nil {
+		<-stopped
 	}
 
-	// Инициализируем MariaDB соединения
-	for _, mariadbCfg := range cfg.MariaDB {
-		conn := connectors.NewMariaDBConnector(mariadbCfg)
-		err := conn.Connect()
-		if err != nil {
-			l.Fatalf("Failed to connect to MariaDB %s: %v", mariadbCfg.Name, err)
+	code := shutdown(sched, connections, cfg.Scheduler.ShutdownGrace(), quit, l)
+	// Аренда освобождается только после того, как синхронизации завершились
+	close(drained)
+	<-stopped
+	// API статуса показывает ход остановки до конца и закрывается последним
+	if statusServer != nil {
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := statusServer.Shutdown(shutdownCtx); err != nil {
+			l.Errorf("Status API shutdown failed: %v", err)
 		}
-		err = conn.Ping()
-		if err != nil {
-			l.Fatalf("Failed to ping MariaDB %s: %v", mariadbCfg.Name, err)
+		cancelShutdown()
+	}
+	return code
+}
+
+// checkSelectedDependencies проверяет, что вышестоящие таблицы зависимых задач
+// тоже выбраны: иначе зависимая задача никогда не запустится
+func checkSelectedDependencies(jobs []*scheduler.Job) error {
+	selected := make(map[string]bool)
+	for _, job := range jobs {
+		selected[job.Name] = true
+	}
+	for _, job := range jobs {
+		for _, upstream := range job.DependsOn {
+			if !selected[upstream] {
+				return fmt.Errorf("table %s depends on %s, which is not selected", job.Name, upstream)
+			}
 		}
-		connections[mariadbCfg.Name] = conn
-		l.Infof("MariaDB connection %s successful", mariadbCfg.Name)
 	}
-	listTransformFunctions := initTransformFunction()
+	return nil
+}
 
-	// Канал для graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+// shutdown дожидается выполняющихся синхронизаций в течение grace, затем прерывает
+// их (повторный сигнал прерывает сразу) и возвращает код завершения
+func shutdown(sched *scheduler.Scheduler, connections map[string]connectors.DatabaseConnector, grace time.Duration, quit <-chan os.Signal, l *logger.Log) int {
+	code := exitOK
+	interrupt := make(chan struct{})
+	go func() {
+		<-quit
+		close(interrupt)
+	}()
+
+	if running := sched.Running(); len(running) > 0 {
+		l.Infof("Waiting up to %s for running syncs: %s (send the signal again to abort)", grace, strings.Join(running, ", "))
+	}
+	if sched.WaitTimeout(grace, interrupt) {
+		l.Info("All sync tasks completed")
+	} else {
+		// Загрузка прерывается после текущей пачки, временные таблицы удаляются
+		l.Errorf("Aborting running syncs: %s", strings.Join(sched.Running(), ", "))
+		sched.Abort()
+		code = exitFailed
+		if !sched.WaitTimeout(grace, nil) {
+			l.Errorf("Syncs did not stop, temp tables may be left behind: %s", strings.Join(sched.Running(), ", "))
+			code = exitForced
+		}
+	}
 
-	// WaitGroup для ожидания завершения всех горутин
-	var wg sync.WaitGroup
+	l.Infof("Application shutdown complete, exit code %d", code)
+	return code
+}
 
+// buildJobs создает задачи синхронизации таблиц из секции sync, для которых include
+// возвращает true (nil - все таблицы). Таблицы, для которых не удалось создать
+// сервис синхронизации, пропускаются с ошибкой в логе
+func buildJobs(cfg *config.Config, connections map[string]connectors.DatabaseConnector, l *logger.Log, include func(syncCfg config.SyncConfig, table config.TableSyncConfig) bool) []*scheduler.Job {
+	listTransformFunctions := initTransformFunction()
+	var jobs []*scheduler.Job
 	for _, syncCfg := range cfg.Sync {
 		sourceConn, ok := connections[syncCfg.SourceDB]
 		if !ok {
@@ -81,85 +217,184 @@ func main() {
 			l.Fatalf("Target DB connection %s not found", syncCfg.TargetDB)
 		}
 
-		// Если есть таблицы, обрабатываем их
-		if len(syncCfg.Tables) > 0 {
-			for _, tableCfg := range syncCfg.Tables {
-				wg.Add(1)
-				go func(cfg config.SyncConfig, table config.TableSyncConfig, listTransformFunctions map[string]func(r domain.Record) domain.Record) {
-					defer wg.Done()
-
-					// Создаем копию конфига синхронизации для таблицы
-					tableSyncCfg := cfg
-					tableSyncCfg.Source = table.Source
-					tableSyncCfg.Target = table.Target
-
-					// Переопределяем параметры, если они заданы для конкретной таблицы
-					if table.BatchSize != nil {
-						tableSyncCfg.BatchSize = *table.BatchSize
-					}
-					if table.TempTableSuffix != nil {
-						tableSyncCfg.TempTableSuffix = *table.TempTableSuffix
-					}
-					if table.BufferSize != nil {
-						tableSyncCfg.BufferSize = *table.BufferSize
-					}
-					if table.SyncInterval != nil {
-						tableSyncCfg.SyncInterval = *table.SyncInterval
-					}
-					if len(table.PostProcedure) > 0 {
-						tableSyncCfg.PostProcedure = table.PostProcedure
-					}
-					var syncService *sims_sync.SyncService
-					transformFunction, ok := listTransformFunctions[tableSyncCfg.TransformFunction]
-					if ok {
-						syncService, err = sims_sync.NewSyncService(
-							sourceConn,
-							targetConn,
-							tableSyncCfg,
-							l,
-							sims_sync.WithTransform(transformFunction))
-					} else {
-						syncService, err = sims_sync.NewSyncService(
-							sourceConn,
-							targetConn,
-							tableSyncCfg,
-							l)
-					}
-					if err != nil {
-						l.Errorf("Failed to create sync service for table %s: %v", table.Source.Table, err)
-						return
-					}
-
-					l.Infof("Starting sync for table %s -> %s", table.Source.Table, table.Target.Table)
-					if err := syncService.Run(); err != nil {
-						l.Errorf("Sync failed for table %s: %v", table.Source.Table, err)
-					}
-				}(syncCfg, tableCfg, listTransformFunctions)
+		var targetMasking []config.MaskRuleConfig
+		if targetDBCfg, err := cfg.FindDatabaseConfig(syncCfg.TargetType, syncCfg.TargetDB); err == nil {
+			targetMasking = targetDBCfg.Masking
+		}
+
+		for _, table := range syncCfg.Tables {
+			if include != nil && !include(syncCfg, table) {
+				continue
 			}
+			tableSyncCfg := applyTableOverrides(syncCfg, table)
+			// Правила целевой БД применяются всегда, правила задачи дополняют их
+			tableSyncCfg.Masking = append(append([]config.MaskRuleConfig{}, targetMasking...), tableSyncCfg.Masking...)
+
+			var opts []sims_sync.ProcessorOption
+			if transformFunction, ok := listTransformFunctions[tableSyncCfg.TransformFunction]; ok {
+				opts = append(opts, sims_sync.WithTransform(transformFunction))
+			}
+			lookupsOK := true
+			for _, lookupCfg := range tableSyncCfg.Lookups {
+				lookup, err := sims_sync.NewLookup(connections[lookupCfg.Connection], lookupCfg)
+				if err != nil {
+					l.Errorf("Failed to create lookup for table %s: %v", table.Source.Table, err)
+					lookupsOK = false
+					break
+				}
+				opts = append(opts, sims_sync.WithLookup(lookup))
+			}
+			if !lookupsOK {
+				continue
+			}
+			syncService, err := sims_sync.NewSyncService(
+				sourceConn,
+				targetConn,
+				tableSyncCfg,
+				l,
+				opts...)
+			if err != nil {
+				l.Errorf("Failed to create sync service for table %s: %v", table.Source.Table, err)
+				continue
+			}
+
+			job, err := syncJob(tableSyncCfg, table, syncService)
+			if err != nil {
+				l.Errorf("Failed to schedule table %s: %v", table.Source.Table, err)
+				continue
+			}
+			jobs = append(jobs, job)
 		}
 	}
 
-	l.Info("Application started successfully")
+	return jobs
+}
 
-	// Ожидаем сигнала завершения
-	<-quit
-	l.Info("Shutting down...")
+// applyTableOverrides возвращает копию конфига синхронизации с параметрами, заданными для таблицы
+func applyTableOverrides(cfg config.SyncConfig, table config.TableSyncConfig) config.SyncConfig {
+	tableSyncCfg := cfg
+	tableSyncCfg.Source = table.Source
+	tableSyncCfg.Target = table.Target
 
-	// Ожидаем завершения всех горутин или таймаут
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
+	if table.BatchSize != nil {
+		tableSyncCfg.BatchSize = *table.BatchSize
+	}
+	if table.TempTableSuffix != nil {
+		tableSyncCfg.TempTableSuffix = *table.TempTableSuffix
+	}
+	if table.BufferSize != nil {
+		tableSyncCfg.BufferSize = *table.BufferSize
+	}
+	if table.SyncInterval != nil {
+		tableSyncCfg.SyncInterval = *table.SyncInterval
+	}
+	if len(table.PostProcedure) > 0 {
+		tableSyncCfg.PostProcedure = table.PostProcedure
+	}
+	if table.StoreAsUTC != nil {
+		tableSyncCfg.StoreAsUTC = *table.StoreAsUTC
+	}
+	if len(table.TimeZones) > 0 {
+		tableSyncCfg.TimeZones = table.TimeZones
+	}
+	if len(table.LOBLimits) > 0 {
+		tableSyncCfg.LOBLimits = table.LOBLimits
+	}
+	if len(table.Masking) > 0 {
+		tableSyncCfg.Masking = table.Masking
+	}
+	if len(table.Lookups) > 0 {
+		tableSyncCfg.Lookups = table.Lookups
+	}
+	if table.Rejects != nil {
+		tableSyncCfg.Rejects = table.Rejects
+	}
+	if table.Validation != nil {
+		tableSyncCfg.Validation = table.Validation
+	}
+	if table.Verify != nil {
+		tableSyncCfg.Verify = table.Verify
+	}
+	if table.Backup != nil {
+		tableSyncCfg.Backup = table.Backup
+	}
+	if table.PublishMode != nil {
+		tableSyncCfg.PublishMode = *table.PublishMode
+	}
+	if table.Partition != nil {
+		tableSyncCfg.Partition = table.Partition
+	}
+	if table.DeferIndexes != nil {
+		tableSyncCfg.DeferIndexes = *table.DeferIndexes
+	}
+	if table.CloneMetadata != nil {
+		tableSyncCfg.CloneMetadata = *table.CloneMetadata
+	}
+	if table.Schedule != nil {
+		tableSyncCfg.Schedule = table.Schedule
+	}
+	if table.Lock != nil {
+		tableSyncCfg.Lock = table.Lock
+	}
+	if table.Retry != nil {
+		tableSyncCfg.Retry = table.Retry
+	}
+	return tableSyncCfg
+}
 
-	select {
-	case <-done:
-		l.Info("All sync tasks completed")
-	case <-time.After(30 * time.Second):
-		l.Error("Timeout while waiting for sync tasks to complete")
+// syncJob создает задачу планировщика для синхронизации таблицы. Без секции schedule
+// таблица синхронизируется при старте и далее каждые sync_interval, с depends_on -
+// после успешной загрузки вышестоящих таблиц
+func syncJob(cfg config.SyncConfig, table config.TableSyncConfig, syncService *sims_sync.SyncService) (*scheduler.Job, error) {
+	// Ограничения одновременных запусков действуют на все БД, которые использует таблица
+	resources := []string{cfg.SourceDB, cfg.TargetDB}
+	for _, lookupCfg := range cfg.Lookups {
+		resources = append(resources, lookupCfg.Connection)
+	}
+	job := &scheduler.Job{
+		Name:      table.JobName(),
+		DependsOn: table.DependsOn,
+		Resources: resources,
+		LockKey:   cfg.TargetDB + "/" + strings.ToLower(table.JobName()), // Таблица одной БД не загружается одновременно
+		Run:       syncService.SyncOnce,
+	}
+	if cfg.Lock != nil && cfg.Lock.Overlap == config.OverlapQueue {
+		job.Overlap = scheduler.OverlapQueue
 	}
-	l.Info("Application shutdown complete")
+	if len(table.DependsOn) > 0 {
+		return job, nil
+	}
+
+	scheduleCfg, schedule, err := tableSchedule(cfg)
+	if err != nil {
+		return nil, err
+	}
+	job.Schedule = schedule
+	job.Jitter = scheduleCfg.Jitter
+	job.RunOnStart = runsOnStart(scheduleCfg)
+	return job, nil
+}
+
+// tableSchedule возвращает секцию schedule таблицы (пустую, если она не задана)
+// и построенное по ней расписание
+func tableSchedule(cfg config.SyncConfig) (*config.ScheduleConfig, scheduler.Schedule, error) {
+	scheduleCfg := cfg.Schedule
+	if scheduleCfg == nil {
+		scheduleCfg = &config.ScheduleConfig{}
+	}
+	schedule, err := scheduleCfg.Build(cfg.SyncInterval)
+	if err != nil {
+		return nil, nil, err
+	}
+	return scheduleCfg, schedule, nil
 }
+
+// runsOnStart сообщает, что таблица синхронизируется сразу после старта:
+// так запускаются таблицы без cron и окон запрета
+func runsOnStart(scheduleCfg *config.ScheduleConfig) bool {
+	return scheduleCfg.Cron == "" && len(scheduleCfg.Blackouts) == 0
+}
+
 func TransformForModelPhones(r domain.Record) domain.Record {
 	// Преобразование имен колонок из source в target
 	if vendorName, exists := r["VENDOR_NAME"]; exists {
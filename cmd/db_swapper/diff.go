@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/services/sims_sync"
+	"flag"
+	"fmt"
+	"logger"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// runDiff выполняет команду diff: сравнивает таблицу источника и цели задачи синхронизации.
+// Возвращает код завершения: 0 - таблицы совпадают, 1 - есть расхождения, 2 - ошибка
+func runDiff(cfg *config.Config, connections map[string]connectors.DatabaseConnector, l *logger.Log, args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	table := fs.String("table", "", "Таблица цели (или источника) из секции sync")
+	columns := fs.String("columns", "", "Колонки цели для сравнения через запятую (по умолчанию все)")
+	keyColumn := fs.String("key", "", "Числовая колонка цели для разбиения на диапазоны")
+	rangeSize := fs.Int64("range", 0, "Размер диапазона ключей")
+	mode := fs.String("mode", "", "Подсчет контрольных сумм: auto, pushdown, stream")
+	output := fs.String("output", "", "Файл детального отчета (.csv или .json)")
+	format := fs.String("format", "", "Формат отчета: csv или json (по умолчанию по расширению файла)")
+	maxDetails := fs.Int("max", 10000, "Максимальное количество строк в отчете (0 - без ограничения)")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if *table == "" {
+		l.Error("diff: -table is required")
+		return exitError
+	}
+
+	syncCfg, ok := findTableSync(cfg, *table)
+	if !ok {
+		l.Errorf("diff: table %s not found in sync config", *table)
+		return exitError
+	}
+	sourceConn, ok := connections[syncCfg.SourceDB]
+	if !ok {
+		l.Errorf("diff: source DB connection %s not found", syncCfg.SourceDB)
+		return exitError
+	}
+	targetConn, ok := connections[syncCfg.TargetDB]
+	if !ok {
+		l.Errorf("diff: target DB connection %s not found", syncCfg.TargetDB)
+		return exitError
+	}
+
+	// Параметры сверки задачи используются как значения по умолчанию
+	opts := sims_sync.DiffOptions{KeyColumn: *keyColumn, RangeSize: *rangeSize, Mode: *mode, MaxDetails: *maxDetails}
+	if v := syncCfg.Verify; v != nil {
+		if opts.KeyColumn == "" {
+			opts.KeyColumn = v.KeyColumn
+		}
+		if opts.RangeSize == 0 {
+			opts.RangeSize = v.RangeSize
+		}
+		if opts.Mode == "" {
+			opts.Mode = v.Mode
+		}
+	}
+	if *columns != "" {
+		for _, c := range strings.Split(*columns, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				opts.Columns = append(opts.Columns, c)
+			}
+		}
+	}
+
+	// Сигнал прерывает выполняющиеся запросы сравнения
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	report, err := sims_sync.Diff(ctx, sourceConn, targetConn, syncCfg, opts)
+	if err != nil {
+		l.Errorf("diff failed: %v", err)
+		return exitError
+	}
+	l.Info(report.Summary())
+	fmt.Println(report.Summary())
+
+	if *output != "" {
+		if err := writeDiffReport(report, *output, *format); err != nil {
+			l.Errorf("diff: write report failed: %v", err)
+			return exitError
+		}
+		l.Infof("Diff report written to %s", *output)
+	}
+
+	if !report.Equal() {
+		return exitFailed
+	}
+	return exitOK
+}
+
+// findTableSync возвращает конфиг синхронизации для таблицы с учетом переопределений таблицы
+func findTableSync(cfg *config.Config, table string) (config.SyncConfig, bool) {
+	for _, syncCfg := range cfg.Sync {
+		if strings.EqualFold(syncCfg.Target.Table, table) || strings.EqualFold(syncCfg.Source.Table, table) {
+			return syncCfg, true
+		}
+		for _, t := range syncCfg.Tables {
+			if strings.EqualFold(t.Target.Table, table) || strings.EqualFold(t.Source.Table, table) {
+				return applyTableOverrides(syncCfg, t), true
+			}
+		}
+	}
+	return config.SyncConfig{}, false
+}
+
+func writeDiffReport(report *sims_sync.DiffReport, path, format string) error {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch format {
+	case "json":
+		return report.WriteJSON(f)
+	case "csv":
+		return report.WriteCSV(f)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
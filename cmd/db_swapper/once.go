@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/scheduler"
+	"errors"
+	"flag"
+	"logger"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// runOnce выполняет команду once: синхронизирует выбранные таблицы один раз и завершается.
+// Таблицы загружаются последовательно с учетом depends_on между выбранными таблицами.
+// Возвращает код завершения: 0 - все таблицы загружены, 1 - есть ошибки, 2 - ошибка параметров
+func runOnce(cfg *config.Config, connections map[string]connectors.DatabaseConnector, l *logger.Log, args []string) int {
+	fs := flag.NewFlagSet("once", flag.ContinueOnError)
+	var sel selector
+	sel.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	include, err := sel.filter(cfg)
+	if err != nil {
+		l.Errorf("once: %v", err)
+		return exitError
+	}
+	jobs := buildJobs(cfg, connections, l, include)
+
+	// Сигнал прерывает загрузку так же, как аварийная остановка демона
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	code := exitOK
+	if len(jobs) < countTables(cfg, include) {
+		// Часть таблиц не удалось подготовить, ошибки уже в логе
+		code = exitFailed
+	}
+	results := make(map[string]bool)
+	for _, job := range orderJobs(jobs) {
+		var failed []string
+		for _, upstream := range job.DependsOn {
+			if ok, ran := results[upstream]; ran && !ok {
+				failed = append(failed, upstream)
+			}
+		}
+		switch {
+		case ctx.Err() != nil:
+			l.Errorf("Job %s skipped: interrupted", job.Name)
+		case len(failed) > 0:
+			l.Errorf("Job %s skipped: upstream %s failed", job.Name, strings.Join(failed, ", "))
+		default:
+			err := job.Run(ctx)
+			switch {
+			case err == nil:
+				results[job.Name] = true
+				continue
+			case errors.Is(err, scheduler.ErrSkipped):
+				// Таблицу загружает другой экземпляр: зависимые таблицы загружаются как обычно
+				l.Infof("Job %s skipped: %v", job.Name, err)
+				continue
+			}
+			l.Errorf("Job %s failed: %v", job.Name, err)
+		}
+		results[job.Name] = false
+		code = exitFailed
+	}
+
+	l.Infof("Run-once complete, exit code %d", code)
+	return code
+}
+
+// countTables возвращает количество выбранных таблиц
+func countTables(cfg *config.Config, include func(syncCfg config.SyncConfig, table config.TableSyncConfig) bool) int {
+	n := 0
+	for _, syncCfg := range cfg.Sync {
+		for _, table := range syncCfg.Tables {
+			if include == nil || include(syncCfg, table) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// orderJobs упорядочивает задачи так, чтобы вышестоящие выполнялись раньше
+// зависимых. Зависимости от невыбранных таблиц не учитываются, в остальном
+// сохраняется порядок конфига. Циклов нет: их отклоняет проверка конфига
+func orderJobs(jobs []*scheduler.Job) []*scheduler.Job {
+	byName := make(map[string]*scheduler.Job)
+	for _, job := range jobs {
+		byName[job.Name] = job
+	}
+	var ordered []*scheduler.Job
+	visited := make(map[*scheduler.Job]bool)
+	var visit func(job *scheduler.Job)
+	visit = func(job *scheduler.Job) {
+		if visited[job] {
+			return
+		}
+		visited[job] = true
+		for _, upstream := range job.DependsOn {
+			if u, ok := byName[upstream]; ok {
+				visit(u)
+			}
+		}
+		ordered = append(ordered, job)
+	}
+	for _, job := range jobs {
+		visit(job)
+	}
+	return ordered
+}
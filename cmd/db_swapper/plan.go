@@ -0,0 +1,100 @@
+package main
+
+import (
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"flag"
+	"fmt"
+	"logger"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// runPlan выполняет команду plan: выводит выбранные таблицы, их расписание и ближайшие
+// запуски без подключения к БД. Время запусков указано без учета jitter.
+// Возвращает код завершения: 0 - план построен, 2 - ошибка параметров или расписания
+func runPlan(cfg *config.Config, _ map[string]connectors.DatabaseConnector, l *logger.Log, args []string) int {
+	fs := flag.NewFlagSet("plan", flag.ContinueOnError)
+	var sel selector
+	sel.register(fs)
+	runs := fs.Int("runs", 3, "Количество ближайших запусков")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	include, err := sel.filter(cfg)
+	if err != nil {
+		l.Errorf("plan: %v", err)
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	now := time.Now()
+	code := exitOK
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TABLE\tSOURCE\tTARGET\tSCHEDULE\tNEXT RUNS")
+	for _, syncCfg := range cfg.Sync {
+		for _, table := range syncCfg.Tables {
+			if include != nil && !include(syncCfg, table) {
+				continue
+			}
+			tableSyncCfg := applyTableOverrides(syncCfg, table)
+			source := syncCfg.SourceDB + "." + tableSyncCfg.Source.Table
+			if tableSyncCfg.Source.Table == "" {
+				source = syncCfg.SourceDB + " (query)"
+			}
+			target := syncCfg.TargetDB + "." + tableSyncCfg.Target.Table
+
+			schedule, next, err := describeSchedule(tableSyncCfg, table, now, *runs)
+			if err != nil {
+				l.Errorf("plan: table %s: %v", table.JobName(), err)
+				schedule, next = "invalid: "+err.Error(), "-"
+				code = exitError
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", table.JobName(), source, target, schedule, next)
+		}
+	}
+	w.Flush()
+	return code
+}
+
+// describeSchedule возвращает описание расписания таблицы и ее ближайшие запуски
+func describeSchedule(cfg config.SyncConfig, table config.TableSyncConfig, now time.Time, runs int) (string, string, error) {
+	if len(table.DependsOn) > 0 {
+		return "after " + strings.Join(table.DependsOn, ", "), "-", nil
+	}
+	scheduleCfg, schedule, err := tableSchedule(cfg)
+	if err != nil {
+		return "", "", err
+	}
+
+	var parts []string
+	if scheduleCfg.Cron != "" {
+		parts = append(parts, "cron "+scheduleCfg.Cron)
+		if scheduleCfg.TimeZone != "" {
+			parts = append(parts, scheduleCfg.TimeZone)
+		}
+	} else {
+		parts = append(parts, "every "+cfg.SyncInterval.String())
+	}
+	if n := len(scheduleCfg.Blackouts); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d blackout windows", n))
+	}
+	if scheduleCfg.Jitter > 0 {
+		parts = append(parts, "jitter "+scheduleCfg.Jitter.String())
+	}
+
+	var next []string
+	t := now
+	if runsOnStart(scheduleCfg) {
+		next = append(next, "on start")
+	}
+	for len(next) < runs {
+		if t = schedule.Next(t); t.IsZero() {
+			break
+		}
+		next = append(next, t.Format("2006-01-02 15:04:05"))
+	}
+	return strings.Join(parts, ", "), strings.Join(next, ", "), nil
+}
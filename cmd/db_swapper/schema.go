@@ -0,0 +1,136 @@
+package main
+
+import (
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/domain"
+	"db_swapper/internal/services/sims_sync"
+	"flag"
+	"fmt"
+	"logger"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// runSchema выполняет команду schema: выводит колонки цели, сопоставленные им колонки
+// источника и колонки источника, которые не загружаются.
+// Возвращает код завершения: 0 - схема выведена, 2 - ошибка
+func runSchema(cfg *config.Config, connections map[string]connectors.DatabaseConnector, l *logger.Log, args []string) int {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	table := fs.String("table", "", "Таблица цели (или источника) из секции sync")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if *table == "" {
+		l.Error("schema: -table is required")
+		return exitError
+	}
+
+	syncCfg, ok := findTableSync(cfg, *table)
+	if !ok {
+		l.Errorf("schema: table %s not found in sync config", *table)
+		return exitError
+	}
+	sourceConn, ok := connections[syncCfg.SourceDB]
+	if !ok {
+		l.Errorf("schema: source DB connection %s not found", syncCfg.SourceDB)
+		return exitError
+	}
+	targetConn, ok := connections[syncCfg.TargetDB]
+	if !ok {
+		l.Errorf("schema: target DB connection %s not found", syncCfg.TargetDB)
+		return exitError
+	}
+
+	sourceFrom := syncCfg.Source.Table
+	if sourceFrom == "" {
+		sourceFrom = fmt.Sprintf("(%s) src", syncCfg.Source.Query)
+	}
+	sourceSchema, err := sourceConn.ExecuteSelectWithSchema(fmt.Sprintf("SELECT * FROM %s WHERE 1=0", sourceFrom))
+	if err != nil {
+		l.Errorf("schema: failed to get source schema: %v", err)
+		return exitError
+	}
+	// Схема цели - из конфига, как при создании таблицы; без колонок в конфиге - из существующей таблицы
+	existing, err := targetConn.ExecuteSelectWithSchema(fmt.Sprintf("SELECT * FROM %s WHERE 1=0", syncCfg.Target.Table))
+	if err != nil {
+		existing = nil // Таблица еще не создана
+	}
+	targetSchema := &domain.TableSchema{PrimaryKey: syncCfg.Target.PrimaryKey}
+	for _, col := range syncCfg.Target.Columns {
+		targetSchema.Columns = append(targetSchema.Columns, domain.ColumnInfo{Name: col.Name, DataType: col.DataType, IsNullable: col.IsNullable})
+	}
+	if len(targetSchema.Columns) == 0 {
+		if existing == nil {
+			l.Errorf("schema: target table %s does not exist and has no columns in config", syncCfg.Target.Table)
+			return exitError
+		}
+		targetSchema.Columns = existing.Columns
+	}
+
+	// Сопоставление колонок то же, что и при синхронизации
+	processor := sims_sync.NewDataProcessor(0, sims_sync.WithSchemas(sourceSchema, targetSchema))
+	mapped := make(map[string]bool)
+
+	fmt.Printf("Source: %s %s\nTarget: %s %s\n\n", syncCfg.SourceDB, sourceFrom, syncCfg.TargetDB, syncCfg.Target.Table)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TARGET COLUMN\tTYPE\tNULL\tSOURCE COLUMN\tSOURCE TYPE\tNOTE")
+	for _, col := range targetSchema.Columns {
+		sourceName, sourceType := "-", "-"
+		if src, ok := findColumn(sourceSchema, processor.SourceColumnFor(col.Name)); ok {
+			sourceName, sourceType = src.Name, src.DataType
+			mapped[strings.ToLower(src.Name)] = true
+		}
+		var notes []string
+		if isKeyColumn(targetSchema.PrimaryKey, col.Name) {
+			notes = append(notes, "primary key")
+		}
+		if existing != nil {
+			if _, ok := findColumn(existing, col.Name); !ok {
+				notes = append(notes, "missing in target table")
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", col.Name, col.DataType, yesNo(col.IsNullable), sourceName, sourceType, strings.Join(notes, ", "))
+	}
+	w.Flush()
+
+	var skipped []string
+	for _, col := range sourceSchema.Columns {
+		if !mapped[strings.ToLower(col.Name)] {
+			skipped = append(skipped, col.Name)
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("\nSource columns not loaded: %s\n", strings.Join(skipped, ", "))
+	}
+	if existing == nil {
+		fmt.Printf("\nTarget table %s does not exist yet\n", syncCfg.Target.Table)
+	}
+	return exitOK
+}
+
+func findColumn(schema *domain.TableSchema, name string) (domain.ColumnInfo, bool) {
+	for _, col := range schema.Columns {
+		if strings.EqualFold(col.Name, name) {
+			return col, true
+		}
+	}
+	return domain.ColumnInfo{}, false
+}
+
+func isKeyColumn(primaryKey, name string) bool {
+	for _, k := range strings.Split(primaryKey, ",") {
+		if strings.EqualFold(strings.TrimSpace(k), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
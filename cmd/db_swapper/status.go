@@ -0,0 +1,238 @@
+package main
+
+import (
+	"crypto/subtle"
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/leader"
+	"db_swapper/internal/scheduler"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"logger"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// statusReport - ответ GET /status
+type statusReport struct {
+	Leader     *leader.Status             `json:"leader,omitempty"`
+	QueueDepth int                        `json:"queue_depth"`
+	Jobs       []jobStatus                `json:"jobs"`
+	Breakers   []connectors.BreakerStatus `json:"breakers"`
+}
+
+type jobStatus struct {
+	Name      string     `json:"name"`
+	NextRun   *time.Time `json:"next_run,omitempty"`
+	Running   bool       `json:"running"`
+	Queued    bool       `json:"queued"`
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	DependsOn []string   `json:"depends_on,omitempty"`
+}
+
+// serveStatus запускает HTTP API статуса (см. statusHandler). Сервер нужно
+// остановить через Shutdown
+func serveStatus(cfg config.StatusConfig, sched *scheduler.Scheduler, elector *leader.Elector, breakers []*connectors.BreakerConnector, l *logger.Log) *http.Server {
+	token := cfg.Token
+	if token == "" {
+		token = os.Getenv("DB_SWAPPER_STATUS_TOKEN")
+	}
+	srv := &http.Server{Addr: cfg.Listen, Handler: statusHandler(sched, elector, breakers, token, l), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			l.Errorf("Status API failed: %v", err)
+		}
+	}()
+	l.Infof("Status API listening on %s", cfg.Listen)
+	if token == "" {
+		l.Info("Status API: status.token is not set, manual runs are accepted only from localhost")
+	}
+	return srv
+}
+
+// statusHandler обслуживает API статуса: GET /status возвращает задачи планировщика,
+// лидера и состояние выключателей подключений, POST /trigger?table=<задача>
+// запускает синхронизацию таблицы вне расписания. Запуск требует заголовка
+// "Authorization: Bearer <token>", а без токена принимается только с локального адреса
+func statusHandler(sched *scheduler.Scheduler, elector *leader.Elector, breakers []*connectors.BreakerConnector, token string, l *logger.Log) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		report := statusReport{QueueDepth: sched.QueueDepth(), Jobs: []jobStatus{}, Breakers: []connectors.BreakerStatus{}}
+		if elector != nil {
+			st := elector.Status()
+			report.Leader = &st
+		}
+		for _, job := range sched.Jobs() {
+			js := jobStatus{
+				Name:      job.Name,
+				NextRun:   optionalTime(job.Next),
+				Running:   job.Running,
+				Queued:    job.Queued,
+				LastRun:   optionalTime(job.LastRun),
+				DependsOn: job.DependsOn,
+			}
+			if job.LastErr != nil {
+				js.LastError = job.LastErr.Error()
+			}
+			report.Jobs = append(report.Jobs, js)
+		}
+		for _, b := range breakers {
+			report.Breakers = append(report.Breakers, b.Status())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			l.Errorf("status: write response failed: %v", err)
+		}
+	})
+
+	mux.HandleFunc("/trigger", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !triggerAllowed(r, token) {
+			l.Errorf("Manual run rejected: unauthorized request from %s", r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		table := r.URL.Query().Get("table")
+		if table == "" {
+			http.Error(w, "table is required", http.StatusBadRequest)
+			return
+		}
+		err := sched.Trigger(table)
+		switch {
+		case err == nil:
+			l.Infof("Manual run of %s requested by %s", table, r.RemoteAddr)
+			w.WriteHeader(http.StatusAccepted)
+		case errors.Is(err, scheduler.ErrUnknownJob):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, scheduler.ErrJobBusy):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			// Резервный экземпляр или остановка: запускает только лидер
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		}
+	})
+
+	return mux
+}
+
+// triggerAllowed проверяет право на ручной запуск: токен в заголовке Authorization,
+// а если токен не настроен - запрос с локального адреса
+func triggerAllowed(r *http.Request, token string) bool {
+	if token != "" {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// runStatus выполняет команду status: запрашивает GET /status у работающего экземпляра
+// и выводит лидера, очередь, задачи и выключатели. Возвращает код завершения:
+// 0 - состояние получено, 2 - ошибка параметров или ответа, 3 - API недоступен
+func runStatus(cfg *config.Config, _ map[string]connectors.DatabaseConnector, l *logger.Log, args []string) int {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	addr := fs.String("addr", cfg.Status.Listen, "Адрес API статуса (по умолчанию status.listen)")
+	raw := fs.Bool("json", false, "Вывести ответ API без форматирования")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if *addr == "" {
+		fmt.Fprintln(os.Stderr, "status: -addr is required when status.listen is not set")
+		return exitError
+	}
+	url := *addr
+	if strings.HasPrefix(url, ":") {
+		url = "localhost" + url
+	}
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimSuffix(url, "/") + "/status")
+	if err != nil {
+		l.Errorf("status: %v", err)
+		fmt.Fprintln(os.Stderr, err)
+		return exitUnavailable
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "status: unexpected response %s: %v\n", resp.Status, err)
+		return exitError
+	}
+	if *raw {
+		os.Stdout.Write(body)
+		return exitOK
+	}
+
+	var report statusReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		fmt.Fprintf(os.Stderr, "status: invalid response: %v\n", err)
+		return exitError
+	}
+	if report.Leader != nil {
+		fmt.Printf("Instance: %s (leader: %s, is leader: %t)\n", report.Leader.Instance, report.Leader.Leader, report.Leader.IsLeader)
+	}
+	fmt.Printf("Queue depth: %d\n\n", report.QueueDepth)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB\tSTATE\tNEXT RUN\tLAST RUN\tLAST ERROR")
+	for _, job := range report.Jobs {
+		state := "idle"
+		switch {
+		case job.Queued:
+			state = "queued"
+		case job.Running:
+			state = "running"
+		}
+		next := formatOptionalTime(job.NextRun)
+		if len(job.DependsOn) > 0 {
+			next = "after " + strings.Join(job.DependsOn, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", job.Name, state, next, formatOptionalTime(job.LastRun), job.LastError)
+	}
+	w.Flush()
+
+	if len(report.Breakers) > 0 {
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CONNECTION\tBREAKER\tFAILURES\tLAST ERROR")
+		for _, b := range report.Breakers {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", b.Connection, b.State, b.Failures, b.LastError)
+		}
+		w.Flush()
+	}
+	return exitOK
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
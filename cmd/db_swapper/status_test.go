@@ -0,0 +1,49 @@
+package main
+
+import (
+	"db_swapper/internal/scheduler"
+	"logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTriggerAuthorization(t *testing.T) {
+	l, err := logger.NewLogger("console", "error", "")
+	if err != nil {
+		t.Fatalf("create logger: %v", err)
+	}
+	// Планировщик не запущен: прошедший проверку запрос получает 503
+	sched := scheduler.New(l)
+
+	tests := []struct {
+		name   string
+		token  string
+		remote string
+		auth   string
+		want   int
+	}{
+		{"no token, remote client", "", "192.0.2.1:41000", "", http.StatusUnauthorized},
+		{"no token, loopback", "", "127.0.0.1:41000", "", http.StatusServiceUnavailable},
+		{"no token, ipv6 loopback", "", "[::1]:41000", "", http.StatusServiceUnavailable},
+		{"token, missing header", "secret", "127.0.0.1:41000", "", http.StatusUnauthorized},
+		{"token, wrong value", "secret", "192.0.2.1:41000", "Bearer wrong", http.StatusUnauthorized},
+		{"token, not bearer", "secret", "192.0.2.1:41000", "secret", http.StatusUnauthorized},
+		{"token, valid", "secret", "192.0.2.1:41000", "Bearer secret", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := statusHandler(sched, nil, nil, tt.token, l)
+			req := httptest.NewRequest(http.MethodPost, "/trigger?table=sims", nil)
+			req.RemoteAddr = tt.remote
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("POST /trigger = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
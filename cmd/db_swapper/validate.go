@@ -0,0 +1,81 @@
+package main
+
+import (
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"flag"
+	"fmt"
+	"logger"
+	"os"
+)
+
+// runValidate выполняет команду validate. Параметры конфига проверяются при загрузке,
+// здесь дополнительно проверяются ссылки на подключения и расписания таблиц, а с -connect -
+// доступность всех БД. Возвращает код завершения: 0 - конфиг корректен, 2 - есть ошибки,
+// 3 - не удалось подключиться к БД
+func runValidate(cfg *config.Config, _ map[string]connectors.DatabaseConnector, l *logger.Log, args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	connectDBs := fs.Bool("connect", false, "Проверить подключение ко всем БД")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+
+	errs := validateReferences(cfg)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if len(errs) > 0 {
+		return exitError
+	}
+
+	if *connectDBs {
+		connections, err := connect(cfg, l)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUnavailable
+		}
+		disconnect(connections, l)
+	}
+
+	tables := countTables(cfg, nil)
+	fmt.Printf("Config is valid: %d databases, %d tables\n", len(cfg.Oracle)+len(cfg.MariaDB), tables)
+	return exitOK
+}
+
+// validateReferences проверяет, что задачи ссылаются на подключения из конфига,
+// а расписания таблиц строятся
+func validateReferences(cfg *config.Config) []error {
+	var errs []error
+	known := func(name string) bool {
+		_, errOracle := cfg.FindDatabaseConfig("oracle", name)
+		_, errMariaDB := cfg.FindDatabaseConfig("mariadb", name)
+		return errOracle == nil || errMariaDB == nil
+	}
+
+	if cfg.Leader != nil && !known(cfg.Leader.Database) {
+		errs = append(errs, fmt.Errorf("leader: database %s not found", cfg.Leader.Database))
+	}
+	for _, syncCfg := range cfg.Sync {
+		if _, err := cfg.FindDatabaseConfig(syncCfg.SourceType, syncCfg.SourceDB); err != nil {
+			errs = append(errs, fmt.Errorf("sync %s -> %s: source: %w", syncCfg.SourceDB, syncCfg.TargetDB, err))
+		}
+		if _, err := cfg.FindDatabaseConfig(syncCfg.TargetType, syncCfg.TargetDB); err != nil {
+			errs = append(errs, fmt.Errorf("sync %s -> %s: target: %w", syncCfg.SourceDB, syncCfg.TargetDB, err))
+		}
+		for _, table := range syncCfg.Tables {
+			tableSyncCfg := applyTableOverrides(syncCfg, table)
+			for _, lookupCfg := range tableSyncCfg.Lookups {
+				if !known(lookupCfg.Connection) {
+					errs = append(errs, fmt.Errorf("table %s: lookup %s: connection %s not found", table.JobName(), lookupCfg.Name, lookupCfg.Connection))
+				}
+			}
+			if len(table.DependsOn) > 0 {
+				continue
+			}
+			if _, _, err := tableSchedule(tableSyncCfg); err != nil {
+				errs = append(errs, fmt.Errorf("table %s: %w", table.JobName(), err))
+			}
+		}
+	}
+	return errs
+}
@@ -2,9 +2,13 @@ package config
 
 import (
 	"db_swapper/internal/domain"
+	"db_swapper/internal/scheduler"
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -16,9 +20,91 @@ type Config struct {
 		Target   string `yaml:"target"`
 		Filename string `yaml:"filename"`
 	} `yaml:"logger"`
-	Oracle  []DatabaseConfig `yaml:"oracle"`
-	MariaDB []DatabaseConfig `yaml:"mariadb"`
-	Sync    []SyncConfig     `yaml:"sync"`
+	Scheduler SchedulerConfig  `yaml:"scheduler"`
+	Leader    *LeaderConfig    `yaml:"leader,omitempty"` // Выбор лидера среди нескольких экземпляров
+	Status    StatusConfig     `yaml:"status"`
+	Oracle    []DatabaseConfig `yaml:"oracle"`
+	MariaDB   []DatabaseConfig `yaml:"mariadb"`
+	Sync      []SyncConfig     `yaml:"sync"`
+}
+
+// SchedulerConfig описывает общие параметры планировщика
+type SchedulerConfig struct {
+	MaxConcurrentSyncs int           `yaml:"max_concurrent_syncs"` // Одновременно выполняющиеся синхронизации (0 - без ограничения)
+	ShutdownTimeout    time.Duration `yaml:"shutdown_timeout"`     // Ожидание синхронизаций при остановке (по умолчанию 30s)
+}
+
+// ShutdownGrace возвращает время ожидания синхронизаций при остановке
+func (c SchedulerConfig) ShutdownGrace() time.Duration {
+	if c.ShutdownTimeout <= 0 {
+		return 30 * time.Second
+	}
+	return c.ShutdownTimeout
+}
+
+// StatusConfig описывает HTTP API статуса
+type StatusConfig struct {
+	Listen string `yaml:"listen,omitempty"` // Адрес, например ":8080" (пусто - API отключен)
+	// Токен для POST /trigger, по умолчанию из переменной окружения. Без токена
+	// ручной запуск принимается только с локального адреса
+	Token string `yaml:"token,omitempty" env:"DB_SWAPPER_STATUS_TOKEN"`
+}
+
+// LeaderConfig описывает выбор лидера через строку аренды в служебной таблице.
+// Синхронизации запускает только лидер
+type LeaderConfig struct {
+	Database  string        `yaml:"database"`            // Имя подключения из секций oracle/mariadb
+	Table     string        `yaml:"table,omitempty"`     // Служебная таблица (по умолчанию db_swapper_lease)
+	Name      string        `yaml:"name,omitempty"`      // Имя аренды (по умолчанию db_swapper), разное для независимых групп
+	Instance  string        `yaml:"instance,omitempty"`  // Идентификатор экземпляра (по умолчанию хост:pid)
+	Timeout   time.Duration `yaml:"timeout,omitempty"`   // Срок аренды: за это время резервный экземпляр заменяет упавшего лидера (по умолчанию 30s)
+	Heartbeat time.Duration `yaml:"heartbeat,omitempty"` // Интервал продления (по умолчанию timeout/3)
+}
+
+// Validate проверяет настройки выбора лидера
+func (l *LeaderConfig) Validate() error {
+	if l.Database == "" {
+		return errors.New("leader database cannot be empty")
+	}
+	if l.Timeout < 0 || l.Heartbeat < 0 {
+		return errors.New("leader timeout and heartbeat cannot be negative")
+	}
+	if l.HeartbeatInterval() >= l.LeaseTimeout() {
+		return errors.New("leader heartbeat must be less than timeout")
+	}
+	return nil
+}
+
+// LeaseTable возвращает имя служебной таблицы аренды
+func (l *LeaderConfig) LeaseTable() string {
+	if l.Table == "" {
+		return "db_swapper_lease"
+	}
+	return l.Table
+}
+
+// LeaseName возвращает имя аренды
+func (l *LeaderConfig) LeaseName() string {
+	if l.Name == "" {
+		return "db_swapper"
+	}
+	return l.Name
+}
+
+// LeaseTimeout возвращает срок аренды
+func (l *LeaderConfig) LeaseTimeout() time.Duration {
+	if l.Timeout == 0 {
+		return 30 * time.Second
+	}
+	return l.Timeout
+}
+
+// HeartbeatInterval возвращает интервал продления аренды
+func (l *LeaderConfig) HeartbeatInterval() time.Duration {
+	if l.Heartbeat == 0 {
+		return l.LeaseTimeout() / 3
+	}
+	return l.Heartbeat
 }
 
 type DatabaseConfig struct {
@@ -30,6 +116,89 @@ type DatabaseConfig struct {
 	DBName   string `yaml:"dbname" env:"db"`
 	SSLMode  string `yaml:"sslmode" env:"db_ssl" default:"disable"`
 	Timeout  int    `yaml:"timeout" env:"db_timeout" default:"5"` // in seconds
+	TimeZone string `yaml:"time_zone" env:"db_time_zone"`         // Пояс, в котором БД хранит DATE/DATETIME (IANA, по умолчанию локальный)
+
+	LOBChunkSize int `yaml:"lob_chunk_size" default:"1048576"` // Размер значения (байт), начиная с которого LOB пишется частями
+
+	Masking []MaskRuleConfig `yaml:"masking,omitempty"` // Маскирование для всех синхронизаций в эту БД
+
+	MaxConcurrentSyncs int `yaml:"max_concurrent_syncs,omitempty"` // Одновременные синхронизации, использующие БД (0 - без ограничения)
+
+	Retry *RetryConfig `yaml:"retry,omitempty"` // Повтор читающих запросов при временных ошибках
+
+	CircuitBreaker *BreakerConfig `yaml:"circuit_breaker,omitempty"` // Отключение запросов к недоступной БД
+}
+
+// BreakerConfig описывает автоматический выключатель подключения
+type BreakerConfig struct {
+	Failures    int           `yaml:"failures,omitempty"`     // Ошибок соединения подряд до размыкания (по умолчанию 5)
+	OpenTimeout time.Duration `yaml:"open_timeout,omitempty"` // Через сколько проверить БД снова (по умолчанию 1m)
+}
+
+// Validate проверяет настройки выключателя
+func (b *BreakerConfig) Validate() error {
+	if b.Failures < 0 {
+		return errors.New("circuit_breaker failures cannot be negative")
+	}
+	if b.OpenTimeout < 0 {
+		return errors.New("circuit_breaker open_timeout cannot be negative")
+	}
+	return nil
+}
+
+// FailureThreshold возвращает количество ошибок до размыкания с учетом значения по умолчанию
+func (b *BreakerConfig) FailureThreshold() int {
+	if b == nil || b.Failures == 0 {
+		return 5
+	}
+	return b.Failures
+}
+
+// ResetTimeout возвращает время до проверки БД с учетом значения по умолчанию
+func (b *BreakerConfig) ResetTimeout() time.Duration {
+	if b == nil || b.OpenTimeout == 0 {
+		return time.Minute
+	}
+	return b.OpenTimeout
+}
+
+// RetryConfig описывает повтор операции при временных ошибках с экспоненциальной паузой
+type RetryConfig struct {
+	Attempts   int           `yaml:"attempts,omitempty"`    // Всего попыток, включая первую (по умолчанию 3)
+	Backoff    time.Duration `yaml:"backoff,omitempty"`     // Пауза перед первым повтором (по умолчанию 1s), далее удваивается
+	MaxBackoff time.Duration `yaml:"max_backoff,omitempty"` // Предельная пауза (по умолчанию 1m)
+}
+
+// Validate проверяет настройки повтора
+func (r *RetryConfig) Validate() error {
+	if r.Attempts < 0 {
+		return errors.New("retry attempts cannot be negative")
+	}
+	if r.Backoff < 0 || r.MaxBackoff < 0 {
+		return errors.New("retry backoff cannot be negative")
+	}
+	return nil
+}
+
+// SyncRetryConfig описывает повторы при загрузке таблицы
+type SyncRetryConfig struct {
+	Batch *RetryConfig `yaml:"batch,omitempty"` // Повтор чтения и вставки пачки
+	Job   *RetryConfig `yaml:"job,omitempty"`   // Повтор всей синхронизации
+}
+
+// Validate проверяет настройки повторов
+func (r *SyncRetryConfig) Validate() error {
+	if r.Batch != nil {
+		if err := r.Batch.Validate(); err != nil {
+			return fmt.Errorf("batch: %w", err)
+		}
+	}
+	if r.Job != nil {
+		if err := r.Job.Validate(); err != nil {
+			return fmt.Errorf("job: %w", err)
+		}
+	}
+	return nil
 }
 
 type SyncConfig struct {
@@ -68,6 +237,87 @@ type SyncConfig struct {
 	BufferSize      int           `yaml:"buffer_size" default:"5000"`
 	SyncInterval    time.Duration `yaml:"sync_interval" default:"5m"`
 	PostProcedure   []Procedure   `yaml:"post_procedure_list"`
+
+	// Часовые пояса
+	StoreAsUTC bool                   `yaml:"store_as_utc"` // Записывать дату/время в цель в UTC
+	TimeZones  []ColumnTimeZoneConfig `yaml:"time_zones,omitempty"`
+
+	// Ограничения размера LOB-колонок источника
+	LOBLimits []LOBLimitConfig `yaml:"lob_limits,omitempty"`
+
+	// Маскирование персональных данных
+	MaskingKey string           `yaml:"masking_key" env:"DB_SWAPPER_MASKING_KEY"` // Ключ HMAC, по умолчанию из переменной окружения
+	Masking    []MaskRuleConfig `yaml:"masking,omitempty"`
+
+	// Обогащение записей из справочников других БД
+	Lookups []LookupConfig `yaml:"lookups,omitempty"`
+
+	// Обработка строк, которые не удалось загрузить
+	Rejects *RejectConfig `yaml:"rejects,omitempty"`
+
+	// Проверки качества данных перед публикацией таблицы
+	Validation *ValidationConfig `yaml:"validation,omitempty"`
+
+	// Сверка источника и загруженных данных перед публикацией таблицы
+	Verify *VerifyConfig `yaml:"verify,omitempty"`
+
+	// Хранение резервных копий таблицы после замены
+	Backup *BackupConfig `yaml:"backup,omitempty"`
+
+	// Способ публикации загруженной таблицы: swap (по умолчанию), blue_green или partition_exchange
+	PublishMode string `yaml:"publish_mode,omitempty"`
+
+	// Секция для режима partition_exchange
+	Partition *PartitionConfig `yaml:"partition,omitempty"`
+
+	// Создавать первичный ключ и индексы после загрузки данных
+	DeferIndexes bool `yaml:"defer_indexes,omitempty"`
+
+	// Переносить внешние ключи, CHECK-ограничения, триггеры, комментарии и права на новую таблицу
+	CloneMetadata bool `yaml:"clone_metadata,omitempty"`
+
+	// Защита от одновременной загрузки одной таблицы
+	Lock *LockConfig `yaml:"lock,omitempty"`
+
+	// Повторы при временных ошибках БД
+	Retry *SyncRetryConfig `yaml:"retry,omitempty"`
+
+	// Расписание запусков. Если не задано, синхронизация выполняется при старте и далее каждые sync_interval
+	Schedule *ScheduleConfig `yaml:"schedule,omitempty"`
+}
+
+// Способы публикации загруженной таблицы
+const (
+	PublishSwap              = "swap"               // Переименование таблиц (SwapTables)
+	PublishBlueGreen         = "blue_green"         // Таблицы <имя>_a/<имя>_b и представление (MariaDB) или синоним (Oracle) <имя>
+	PublishPartitionExchange = "partition_exchange" // Oracle: ALTER TABLE ... EXCHANGE PARTITION
+)
+
+// validatePublishMode проверяет способ публикации
+func validatePublishMode(mode string) error {
+	switch mode {
+	case "", PublishSwap, PublishBlueGreen, PublishPartitionExchange:
+		return nil
+	default:
+		return fmt.Errorf("publish_mode must be one of %s, %s, %s", PublishSwap, PublishBlueGreen, PublishPartitionExchange)
+	}
+}
+
+// PartitionConfig описывает заменяемую секцию Oracle
+type PartitionConfig struct {
+	Name             string `yaml:"name,omitempty"`              // Имя секции
+	KeyValue         string `yaml:"key_value,omitempty"`         // Значение ключа секционирования: today, yesterday или SQL-литерал
+	IncludingIndexes bool   `yaml:"including_indexes,omitempty"` // Обменивать локальные индексы
+	WithValidation   bool   `yaml:"with_validation,omitempty"`   // Проверять принадлежность строк секции
+	GatherStats      *bool  `yaml:"gather_stats,omitempty"`      // Собирать статистику секции (по умолчанию да)
+}
+
+// Validate проверяет настройки секции
+func (p *PartitionConfig) Validate() error {
+	if (p.Name == "") == (p.KeyValue == "") {
+		return errors.New("partition requires exactly one of name or key_value")
+	}
+	return nil
 }
 
 // Новая структура для конфигурации синхронизации отдельной таблицы
@@ -89,11 +339,502 @@ type TableSyncConfig struct {
 	} `yaml:"target" json:"target"`
 
 	// Индивидуальные параметры для конкретной таблицы
-	BatchSize       *int           `yaml:"batch_size,omitempty"` // Если не указано, используется общее значение
-	TempTableSuffix *string        `yaml:"temp_table_suffix,omitempty"`
-	BufferSize      *int           `yaml:"buffer_size,omitempty"`
-	SyncInterval    *time.Duration `yaml:"sync_interval,omitempty"`
-	PostProcedure   []Procedure    `yaml:"post_procedure_list,omitempty"`
+	BatchSize       *int                   `yaml:"batch_size,omitempty"` // Если не указано, используется общее значение
+	TempTableSuffix *string                `yaml:"temp_table_suffix,omitempty"`
+	BufferSize      *int                   `yaml:"buffer_size,omitempty"`
+	SyncInterval    *time.Duration         `yaml:"sync_interval,omitempty"`
+	PostProcedure   []Procedure            `yaml:"post_procedure_list,omitempty"`
+	StoreAsUTC      *bool                  `yaml:"store_as_utc,omitempty"`
+	TimeZones       []ColumnTimeZoneConfig `yaml:"time_zones,omitempty"`
+	LOBLimits       []LOBLimitConfig       `yaml:"lob_limits,omitempty"`
+	Masking         []MaskRuleConfig       `yaml:"masking,omitempty"`
+	Lookups         []LookupConfig         `yaml:"lookups,omitempty"`
+	Rejects         *RejectConfig          `yaml:"rejects,omitempty"`
+	Validation      *ValidationConfig      `yaml:"validation,omitempty"`
+	Verify          *VerifyConfig          `yaml:"verify,omitempty"`
+	Backup          *BackupConfig          `yaml:"backup,omitempty"`
+	PublishMode     *string                `yaml:"publish_mode,omitempty"`
+	Partition       *PartitionConfig       `yaml:"partition,omitempty"`
+	DeferIndexes    *bool                  `yaml:"defer_indexes,omitempty"`
+	CloneMetadata   *bool                  `yaml:"clone_metadata,omitempty"`
+	Schedule        *ScheduleConfig        `yaml:"schedule,omitempty"`
+	Lock            *LockConfig            `yaml:"lock,omitempty"`
+	Retry           *SyncRetryConfig       `yaml:"retry,omitempty"`
+	DependsOn       []string               `yaml:"depends_on,omitempty"` // Таблицы, после успешной загрузки которых запускается эта
+}
+
+// JobName возвращает имя задачи таблицы, по которому на нее ссылается depends_on
+func (t *TableSyncConfig) JobName() string {
+	if t.Target.Table != "" {
+		return t.Target.Table
+	}
+	return t.Source.Table
+}
+
+// validateDependencies проверяет, что depends_on ссылается на существующие таблицы
+// и зависимости не образуют цикл
+func validateDependencies(syncs []SyncConfig) error {
+	deps := make(map[string][]string)
+	duplicates := make(map[string]bool)
+	for _, syncCfg := range syncs {
+		for _, table := range syncCfg.Tables {
+			name := table.JobName()
+			if _, ok := deps[name]; ok {
+				duplicates[name] = true
+			}
+			deps[name] = append(deps[name], table.DependsOn...)
+		}
+	}
+	for name, upstream := range deps {
+		for _, u := range upstream {
+			if _, ok := deps[u]; !ok {
+				return fmt.Errorf("table %s depends on unknown table %s", name, u)
+			}
+			// Одноименные таблицы в разных БД различить по имени нельзя
+			if duplicates[u] {
+				return fmt.Errorf("table %s depends on ambiguous table %s", name, u)
+			}
+		}
+	}
+
+	// Поиск в глубину: 1 - вершина в текущем пути, 2 - обработана
+	state := make(map[string]int)
+	var path []string
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			for i, p := range path {
+				if p == name {
+					return fmt.Errorf("dependency cycle: %s -> %s", strings.Join(path[i:], " -> "), name)
+				}
+			}
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		path = append(path, name)
+		for _, u := range deps[name] {
+			if err := visit(u); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = 2
+		return nil
+	}
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Политики пересечения запусков одной таблицы
+const (
+	OverlapSkip  = "skip"  // Пропустить запуск
+	OverlapQueue = "queue" // Дождаться завершения текущего запуска
+)
+
+// LockConfig описывает защиту от одновременной загрузки одной таблицы. Внутри процесса
+// запуски одной целевой таблицы не пересекаются всегда; distributed дополнительно
+// берет блокировку в целевой БД (GET_LOCK в MariaDB, DBMS_LOCK в Oracle) для нескольких экземпляров
+type LockConfig struct {
+	Overlap     string        `yaml:"overlap,omitempty"`     // skip (по умолчанию) или queue
+	Distributed bool          `yaml:"distributed,omitempty"` // Блокировка в целевой БД
+	Timeout     time.Duration `yaml:"timeout,omitempty"`     // Ожидание блокировки в БД при overlap: queue (по умолчанию 30m)
+}
+
+// Validate проверяет настройки блокировки
+func (l *LockConfig) Validate() error {
+	switch l.Overlap {
+	case "", OverlapSkip, OverlapQueue:
+	default:
+		return fmt.Errorf("lock overlap must be one of %s, %s", OverlapSkip, OverlapQueue)
+	}
+	if l.Timeout < 0 {
+		return errors.New("lock timeout cannot be negative")
+	}
+	return nil
+}
+
+// WaitTimeout возвращает время ожидания блокировки в БД: при skip блокировка
+// проверяется без ожидания
+func (l *LockConfig) WaitTimeout() time.Duration {
+	if l.Overlap != OverlapQueue {
+		return 0
+	}
+	if l.Timeout == 0 {
+		return 30 * time.Minute
+	}
+	return l.Timeout
+}
+
+// ScheduleConfig описывает расписание запусков задачи
+type ScheduleConfig struct {
+	Cron      string           `yaml:"cron,omitempty"`      // Cron-выражение, @daily, @every 15m и т.п. (пусто - каждые sync_interval)
+	TimeZone  string           `yaml:"time_zone,omitempty"` // Пояс, в котором вычисляется расписание и окна запрета
+	Blackouts []BlackoutConfig `yaml:"blackouts,omitempty"` // Окна, в которые запуск запрещен
+	Jitter    time.Duration    `yaml:"jitter,omitempty"`    // Случайная задержка запуска от 0 до jitter
+}
+
+// BlackoutConfig - ежедневное окно запрета запусков
+type BlackoutConfig struct {
+	Start string   `yaml:"start"`          // Начало окна, ЧЧ:ММ
+	End   string   `yaml:"end"`            // Конец окна, ЧЧ:ММ (меньше start - окно через полночь)
+	Days  []string `yaml:"days,omitempty"` // Дни недели: mon, tue, ... (пусто - все дни)
+}
+
+// Location возвращает пояс расписания
+func (s *ScheduleConfig) Location() (*time.Location, error) {
+	return domain.LoadLocation(s.TimeZone)
+}
+
+// Build строит расписание. interval используется, если cron не задан
+func (s *ScheduleConfig) Build(interval time.Duration) (scheduler.Schedule, error) {
+	loc, err := s.Location()
+	if err != nil {
+		return nil, err
+	}
+	var base scheduler.Schedule
+	if s.Cron != "" {
+		if base, err = scheduler.ParseCron(s.Cron, loc); err != nil {
+			return nil, fmt.Errorf("invalid cron: %w", err)
+		}
+	} else {
+		if interval <= 0 {
+			return nil, errors.New("schedule requires cron or positive sync_interval")
+		}
+		base = scheduler.Every(interval)
+	}
+	var windows []scheduler.Window
+	for _, b := range s.Blackouts {
+		w, err := scheduler.ParseWindow(b.Start, b.End, b.Days, loc)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return scheduler.WithBlackouts(base, windows), nil
+}
+
+// Validate проверяет расписание
+func (s *ScheduleConfig) Validate() error {
+	if s.Jitter < 0 {
+		return errors.New("schedule jitter cannot be negative")
+	}
+	// Интервал проверяется при запуске, здесь важен только разбор выражения и окон
+	_, err := s.Build(time.Minute)
+	return err
+}
+
+// BackupConfig описывает хранение резервных копий, которые SwapTables оставляет
+// под именем <таблица>_bk_<ГГГГММДДччммсс> (длинное имя таблицы сокращается с хэшем)
+type BackupConfig struct {
+	Keep   int           `yaml:"keep,omitempty"`    // Количество хранимых копий (по умолчанию 1)
+	MaxAge time.Duration `yaml:"max_age,omitempty"` // Копии старше удаляются (0 - без ограничения)
+}
+
+// Validate проверяет настройки хранения резервных копий
+func (b *BackupConfig) Validate() error {
+	if b.Keep < 0 {
+		return errors.New("backup keep cannot be negative")
+	}
+	if b.MaxAge < 0 {
+		return errors.New("backup max_age cannot be negative")
+	}
+	return nil
+}
+
+// KeepCount возвращает количество хранимых копий с учетом значения по умолчанию
+func (b *BackupConfig) KeepCount() int {
+	if b == nil || b.Keep == 0 {
+		return 1
+	}
+	return b.Keep
+}
+
+// VerifyConfig описывает сверку количества строк и контрольной суммы
+// источника и временной таблицы перед SwapTables
+type VerifyConfig struct {
+	Columns   []string `yaml:"columns,omitempty"`    // Колонки цели для контрольной суммы (пусто - только количество строк)
+	KeyColumn string   `yaml:"key_column,omitempty"` // Числовой ключ цели для разбиения на диапазоны
+	RangeSize int64    `yaml:"range_size,omitempty"` // Размер диапазона ключей (по умолчанию 100000)
+	Mode      string   `yaml:"mode,omitempty"`       // auto (по умолчанию), pushdown, stream
+}
+
+// Validate проверяет настройки сверки
+func (v *VerifyConfig) Validate() error {
+	switch v.Mode {
+	case "", "auto", "pushdown", "stream":
+	default:
+		return errors.New("verify mode must be one of auto, pushdown, stream")
+	}
+	if v.RangeSize < 0 {
+		return errors.New("verify range_size cannot be negative")
+	}
+	return nil
+}
+
+// CheckProcessedColumns проверяет, что колонки сверки не меняются при обработке.
+// Суммы источника считаются по исходным строкам, поэтому колонки с маскированием,
+// значениями из справочников или переводом времени в другой пояс никогда не совпадут
+// с целью. columns - колонки цели (нужны, чтобы найти дату/время при store_as_utc)
+func (v *VerifyConfig) CheckProcessedColumns(columns []ColumnConfig, storeAsUTC bool, timeZones []ColumnTimeZoneConfig, masking []MaskRuleConfig, lookups []LookupConfig) error {
+	processed := make(map[string]string)
+	for _, r := range masking {
+		processed[strings.ToLower(r.Column)] = "masked"
+	}
+	for _, l := range lookups {
+		column := l.TargetColumn
+		if column == "" {
+			column = l.ValueColumn
+		}
+		processed[strings.ToLower(column)] = "filled by lookup " + l.Name
+	}
+	for _, z := range timeZones {
+		processed[strings.ToLower(z.Column)] = "converted between time zones"
+	}
+	if storeAsUTC {
+		for _, c := range columns {
+			if t := strings.ToUpper(c.DataType); strings.Contains(t, "DATE") || strings.Contains(t, "TIME") {
+				processed[strings.ToLower(c.Name)] = "converted to UTC"
+			}
+		}
+	}
+
+	for _, c := range append([]string{v.KeyColumn}, v.Columns...) {
+		if reason, ok := processed[strings.ToLower(c)]; ok && c != "" {
+			return fmt.Errorf("verify column %s is %s and cannot match the source", c, reason)
+		}
+	}
+	return nil
+}
+
+// ValidateVerifyColumns проверяет колонки сверки итогового конфига таблицы
+func (c *SyncConfig) ValidateVerifyColumns() error {
+	if c.Verify == nil {
+		return nil
+	}
+	return c.Verify.CheckProcessedColumns(c.Target.Columns, c.StoreAsUTC, c.TimeZones, c.Masking, c.Lookups)
+}
+
+// ValidationConfig описывает правила качества данных, которые проверяются
+// на временной таблице перед SwapTables. При нарушении публикация отменяется
+type ValidationConfig struct {
+	NotNull       []string            `yaml:"not_null,omitempty"`       // Колонки без NULL
+	Unique        []string            `yaml:"unique,omitempty"`         // Уникальные колонки или их сочетания ("a,b")
+	Formats       []FormatRule        `yaml:"formats,omitempty"`        // Проверки формата
+	AllowedValues []AllowedValuesRule `yaml:"allowed_values,omitempty"` // Допустимые значения
+	RowCount      *RowCountRule       `yaml:"row_count,omitempty"`      // Границы количества строк
+}
+
+// FormatRule проверяет значение колонки регулярным выражением и/или длиной
+type FormatRule struct {
+	Column    string `yaml:"column"`
+	Regex     string `yaml:"regex,omitempty"`
+	MinLength int    `yaml:"min_length,omitempty"`
+	MaxLength int    `yaml:"max_length,omitempty"`
+}
+
+// AllowedValuesRule ограничивает значения колонки набором
+type AllowedValuesRule struct {
+	Column string   `yaml:"column"`
+	Values []string `yaml:"values"`
+}
+
+// RowCountRule задает абсолютные границы количества строк и допустимое
+// отношение к количеству строк предыдущей версии таблицы
+type RowCountRule struct {
+	Min      int64   `yaml:"min,omitempty"`
+	Max      int64   `yaml:"max,omitempty"`
+	MinRatio float64 `yaml:"min_ratio,omitempty"` // Например 0.9 - не меньше 90% от предыдущей версии
+	MaxRatio float64 `yaml:"max_ratio,omitempty"` // Например 1.5 - не больше 150% от предыдущей версии
+}
+
+// Validate проверяет описание правил
+func (v *ValidationConfig) Validate() error {
+	for _, f := range v.Formats {
+		if f.Column == "" {
+			return errors.New("format rule column cannot be empty")
+		}
+		if f.Regex == "" && f.MinLength == 0 && f.MaxLength == 0 {
+			return fmt.Errorf("format rule %s: regex, min_length or max_length is required", f.Column)
+		}
+		if f.Regex != "" {
+			if _, err := regexp.Compile(f.Regex); err != nil {
+				return fmt.Errorf("format rule %s: invalid regex: %w", f.Column, err)
+			}
+		}
+	}
+	for _, a := range v.AllowedValues {
+		if a.Column == "" || len(a.Values) == 0 {
+			return errors.New("allowed_values rule must have column and values")
+		}
+	}
+	if rc := v.RowCount; rc != nil {
+		if rc.Min < 0 || rc.Max < 0 || rc.MinRatio < 0 || rc.MaxRatio < 0 {
+			return errors.New("row_count limits cannot be negative")
+		}
+		if rc.Max > 0 && rc.Min > rc.Max {
+			return errors.New("row_count min cannot be greater than max")
+		}
+		if rc.MaxRatio > 0 && rc.MinRatio > rc.MaxRatio {
+			return errors.New("row_count min_ratio cannot be greater than max_ratio")
+		}
+	}
+	return nil
+}
+
+// RejectConfig описывает политику обработки плохих строк.
+// Если пачка не вставилась, она делится пополам до отдельных строк,
+// которые сохраняются в таблицу и/или файл, а загрузка продолжается
+type RejectConfig struct {
+	Table            string  `yaml:"table,omitempty"`              // Таблица отказов в целевой БД
+	File             string  `yaml:"file,omitempty"`               // JSONL-файл отказов
+	MaxRejects       int     `yaml:"max_rejects,omitempty"`        // Максимум отказов за прогон (0 - без ограничения)
+	MaxRejectPercent float64 `yaml:"max_reject_percent,omitempty"` // Максимальная доля отказов в процентах (0 - без ограничения)
+}
+
+// Validate проверяет политику отказов
+func (r *RejectConfig) Validate() error {
+	if r.Table == "" && r.File == "" {
+		return errors.New("rejects must have table or file")
+	}
+	if r.MaxRejects < 0 {
+		return errors.New("max_rejects cannot be negative")
+	}
+	if r.MaxRejectPercent < 0 || r.MaxRejectPercent > 100 {
+		return errors.New("max_reject_percent must be between 0 and 100")
+	}
+	return nil
+}
+
+// Режимы обновления справочника
+const (
+	LookupRefreshRun   = "run"   // Перечитывать в начале каждого прогона (по умолчанию)
+	LookupRefreshNever = "never" // Загрузить один раз при первом прогоне
+)
+
+// LookupConfig описывает справочник для обогащения записей
+type LookupConfig struct {
+	Name         string      `yaml:"name"`
+	Connection   string      `yaml:"connection"`              // Имя БД из секций oracle/mariadb
+	Table        string      `yaml:"table,omitempty"`         // Таблица справочника (альтернатива - query)
+	Query        string      `yaml:"query,omitempty"`         // Запрос, возвращающий key_column и value_column
+	KeyColumn    string      `yaml:"key_column"`              // Ключ в справочнике
+	ValueColumn  string      `yaml:"value_column"`            // Значение в справочнике
+	SourceColumn string      `yaml:"source_column"`           // Колонка записи с ключом
+	TargetColumn string      `yaml:"target_column,omitempty"` // Колонка записи для значения (по умолчанию value_column)
+	OnMiss       string      `yaml:"on_miss,omitempty"`       // null (по умолчанию), default, reject
+	Default      interface{} `yaml:"default,omitempty"`       // Значение для on_miss: default
+	CacheSize    int         `yaml:"cache_size,omitempty"`    // 0 - весь справочник в памяти, >0 - LRU с дозагрузкой по ключу
+	Refresh      string      `yaml:"refresh,omitempty"`       // run (по умолчанию) или never
+}
+
+// Validate проверяет описание справочника
+func (l *LookupConfig) Validate() error {
+	if l.Name == "" {
+		return errors.New("lookup name cannot be empty")
+	}
+	if l.Connection == "" {
+		return fmt.Errorf("lookup %s: connection cannot be empty", l.Name)
+	}
+	if (l.Table == "") == (l.Query == "") {
+		return fmt.Errorf("lookup %s: must have either table or query", l.Name)
+	}
+	if l.KeyColumn == "" || l.ValueColumn == "" || l.SourceColumn == "" {
+		return fmt.Errorf("lookup %s: key_column, value_column and source_column are required", l.Name)
+	}
+	switch l.OnMiss {
+	case "", "null", "default", "reject":
+	default:
+		return fmt.Errorf("lookup %s: on_miss must be one of null, default, reject", l.Name)
+	}
+	switch l.Refresh {
+	case "", LookupRefreshRun, LookupRefreshNever:
+	default:
+		return fmt.Errorf("lookup %s: refresh must be either run or never", l.Name)
+	}
+	if l.CacheSize < 0 {
+		return fmt.Errorf("lookup %s: cache_size cannot be negative", l.Name)
+	}
+	return nil
+}
+
+// MaskRuleConfig описывает маскирование колонки
+type MaskRuleConfig struct {
+	Column     string `yaml:"column"`                // Имя колонки в цели (после трансформации)
+	Method     string `yaml:"method"`                // hmac, format_preserving, redact, null
+	KeepPrefix int    `yaml:"keep_prefix,omitempty"` // Сколько первых символов (для format_preserving - цифр) оставить
+	KeepSuffix int    `yaml:"keep_suffix,omitempty"` // Сколько последних символов оставить (redact)
+	MaskChar   string `yaml:"mask_char,omitempty"`   // Символ замены (redact), по умолчанию "*"
+	Length     int    `yaml:"length,omitempty"`      // Длина результата hmac (по умолчанию 64)
+}
+
+// Validate проверяет правило маскирования
+func (r *MaskRuleConfig) Validate() error {
+	if r.Column == "" {
+		return errors.New("masking column cannot be empty")
+	}
+	switch r.Method {
+	case "hmac", "format_preserving", "redact", "null":
+	default:
+		return fmt.Errorf("column %s: masking method must be one of hmac, format_preserving, redact, null", r.Column)
+	}
+	if r.KeepPrefix < 0 || r.KeepSuffix < 0 || r.Length < 0 {
+		return fmt.Errorf("column %s: keep_prefix, keep_suffix and length cannot be negative", r.Column)
+	}
+	return nil
+}
+
+// LOBLimitConfig ограничивает размер значения CLOB/BLOB (TEXT/BLOB) колонки источника
+type LOBLimitConfig struct {
+	Column   string `yaml:"column"`    // Имя колонки в источнике
+	MaxSize  int64  `yaml:"max_size"`  // Байты, для текстовых LOB - символы
+	OnExceed string `yaml:"on_exceed"` // truncate, skip_row или fail (по умолчанию fail)
+}
+
+// Validate проверяет лимит и политику превышения
+func (l *LOBLimitConfig) Validate() error {
+	if l.Column == "" {
+		return errors.New("lob limit column cannot be empty")
+	}
+	if l.MaxSize <= 0 {
+		return fmt.Errorf("column %s: max_size must be positive", l.Column)
+	}
+	switch l.OnExceed {
+	case "", domain.LOBTruncate, domain.LOBSkipRow, domain.LOBFail:
+		return nil
+	default:
+		return fmt.Errorf("column %s: on_exceed must be one of truncate, skip_row, fail", l.Column)
+	}
+}
+
+// ColumnTimeZoneConfig переопределяет часовые пояса для отдельной колонки
+type ColumnTimeZoneConfig struct {
+	Column     string `yaml:"column"`                // Имя колонки в цели (после трансформации)
+	SourceZone string `yaml:"source_zone,omitempty"` // Пояс, в котором значение хранится в источнике
+	TargetZone string `yaml:"target_zone,omitempty"` // Пояс, в котором значение записывается в цель
+}
+
+// Validate проверяет, что указанные пояса существуют
+func (z *ColumnTimeZoneConfig) Validate() error {
+	if z.Column == "" {
+		return errors.New("time zone column cannot be empty")
+	}
+	for _, name := range []string{z.SourceZone, z.TargetZone} {
+		if _, err := domain.LoadLocation(name); err != nil {
+			return fmt.Errorf("column %s: %w", z.Column, err)
+		}
+	}
+	return nil
 }
 
 type ColumnConfig struct {
@@ -127,6 +868,129 @@ func (c *SyncConfig) Validate() error {
 		return errors.New("target_db cannot be empty")
 	}
 
+	for _, z := range c.TimeZones {
+		if err := z.Validate(); err != nil {
+			return fmt.Errorf("invalid time_zones: %w", err)
+		}
+	}
+	for _, l := range c.LOBLimits {
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("invalid lob_limits: %w", err)
+		}
+	}
+	for _, r := range c.Masking {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("invalid masking: %w", err)
+		}
+	}
+	for _, l := range c.Lookups {
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("invalid lookups: %w", err)
+		}
+	}
+	if c.Rejects != nil {
+		if err := c.Rejects.Validate(); err != nil {
+			return fmt.Errorf("invalid rejects: %w", err)
+		}
+	}
+	if c.Validation != nil {
+		if err := c.Validation.Validate(); err != nil {
+			return fmt.Errorf("invalid validation: %w", err)
+		}
+	}
+	if c.Verify != nil {
+		if err := c.Verify.Validate(); err != nil {
+			return fmt.Errorf("invalid verify: %w", err)
+		}
+	}
+	if c.Backup != nil {
+		if err := c.Backup.Validate(); err != nil {
+			return fmt.Errorf("invalid backup: %w", err)
+		}
+	}
+	if err := validatePublishMode(c.PublishMode); err != nil {
+		return err
+	}
+	if c.Partition != nil {
+		if err := c.Partition.Validate(); err != nil {
+			return fmt.Errorf("invalid partition: %w", err)
+		}
+	}
+	if c.Schedule != nil {
+		if err := c.Schedule.Validate(); err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+	}
+	if c.Lock != nil {
+		if err := c.Lock.Validate(); err != nil {
+			return fmt.Errorf("invalid lock: %w", err)
+		}
+	}
+	if c.Retry != nil {
+		if err := c.Retry.Validate(); err != nil {
+			return fmt.Errorf("invalid retry: %w", err)
+		}
+	}
+
+	// Хеш-функции диалектов различаются: pushdown сравнивает суммы разных алгоритмов
+	if c.SourceType != c.TargetType {
+		if c.Verify != nil && c.Verify.Mode == "pushdown" {
+			return errors.New("invalid verify: mode pushdown requires source and target of the same type")
+		}
+		for _, table := range c.Tables {
+			if table.Verify != nil && table.Verify.Mode == "pushdown" {
+				return fmt.Errorf("invalid table config: table %s: verify mode pushdown requires source and target of the same type", table.JobName())
+			}
+		}
+	}
+
+	if len(c.Tables) == 0 {
+		if err := c.ValidateVerifyColumns(); err != nil {
+			return fmt.Errorf("invalid verify: %w", err)
+		}
+	}
+	for _, table := range c.Tables {
+		verify := c.Verify
+		if table.Verify != nil {
+			verify = table.Verify
+		}
+		if verify == nil {
+			continue
+		}
+		// Параметры таблицы заменяют общие так же, как при создании задачи
+		storeAsUTC, timeZones, masking, lookups := c.StoreAsUTC, c.TimeZones, c.Masking, c.Lookups
+		if table.StoreAsUTC != nil {
+			storeAsUTC = *table.StoreAsUTC
+		}
+		if len(table.TimeZones) > 0 {
+			timeZones = table.TimeZones
+		}
+		if len(table.Masking) > 0 {
+			masking = table.Masking
+		}
+		if len(table.Lookups) > 0 {
+			lookups = table.Lookups
+		}
+		if err := verify.CheckProcessedColumns(table.Target.Columns, storeAsUTC, timeZones, masking, lookups); err != nil {
+			return fmt.Errorf("invalid table config: table %s: %w", table.JobName(), err)
+		}
+	}
+
+	// Дубликаты ключа при defer_indexes обнаруживаются только при построении ключа,
+	// когда строки уже вставлены, поэтому rejects не может их изолировать
+	if len(c.Tables) == 0 && c.DeferIndexes && c.Rejects != nil {
+		return errors.New("defer_indexes cannot be combined with rejects")
+	}
+	for _, table := range c.Tables {
+		deferIndexes := c.DeferIndexes
+		if table.DeferIndexes != nil {
+			deferIndexes = *table.DeferIndexes
+		}
+		if deferIndexes && (c.Rejects != nil || table.Rejects != nil) {
+			return fmt.Errorf("invalid table config: table %s: defer_indexes cannot be combined with rejects", table.JobName())
+		}
+	}
+
 	// Если есть таблицы, валидируем их
 	if len(c.Tables) > 0 {
 		for _, table := range c.Tables {
@@ -170,11 +1034,77 @@ func (t *TableSyncConfig) Validate() error {
 		return errors.New("target cannot have both table and query")
 	}
 
+	for _, z := range t.TimeZones {
+		if err := z.Validate(); err != nil {
+			return fmt.Errorf("invalid time_zones: %w", err)
+		}
+	}
+	for _, l := range t.LOBLimits {
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("invalid lob_limits: %w", err)
+		}
+	}
+	for _, r := range t.Masking {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("invalid masking: %w", err)
+		}
+	}
+	for _, l := range t.Lookups {
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("invalid lookups: %w", err)
+		}
+	}
+	if t.Rejects != nil {
+		if err := t.Rejects.Validate(); err != nil {
+			return fmt.Errorf("invalid rejects: %w", err)
+		}
+	}
+	if t.Validation != nil {
+		if err := t.Validation.Validate(); err != nil {
+			return fmt.Errorf("invalid validation: %w", err)
+		}
+	}
+	if t.Verify != nil {
+		if err := t.Verify.Validate(); err != nil {
+			return fmt.Errorf("invalid verify: %w", err)
+		}
+	}
+	if t.Backup != nil {
+		if err := t.Backup.Validate(); err != nil {
+			return fmt.Errorf("invalid backup: %w", err)
+		}
+	}
+	if t.PublishMode != nil {
+		if err := validatePublishMode(*t.PublishMode); err != nil {
+			return err
+		}
+	}
+	if t.Partition != nil {
+		if err := t.Partition.Validate(); err != nil {
+			return fmt.Errorf("invalid partition: %w", err)
+		}
+	}
+	if t.Schedule != nil {
+		if err := t.Schedule.Validate(); err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+	}
+	if t.Lock != nil {
+		if err := t.Lock.Validate(); err != nil {
+			return fmt.Errorf("invalid lock: %w", err)
+		}
+	}
+	if t.Retry != nil {
+		if err := t.Retry.Validate(); err != nil {
+			return fmt.Errorf("invalid retry: %w", err)
+		}
+	}
+
 	return nil
 }
 
 func GetConfig(filename string) (*Config, error) {
-	f, err := os.Open("./" + filename)
+	f, err := os.Open(filename)
 	if err != nil {
 		return nil, fmt.Errorf("error opening config file: %w", err)
 	}
@@ -186,12 +1116,52 @@ func GetConfig(filename string) (*Config, error) {
 		return nil, fmt.Errorf("error decoding YAML: %w", err)
 	}
 
+	// Проверяем часовые пояса подключений
+	for _, db := range append(append([]DatabaseConfig{}, cfg.Oracle...), cfg.MariaDB...) {
+		if _, err := domain.LoadLocation(db.TimeZone); err != nil {
+			return nil, fmt.Errorf("invalid database config %s: %w", db.Name, err)
+		}
+		if db.Retry != nil {
+			if err := db.Retry.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid database config %s: %w", db.Name, err)
+			}
+		}
+		if db.CircuitBreaker != nil {
+			if err := db.CircuitBreaker.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid database config %s: %w", db.Name, err)
+			}
+		}
+		if db.MaxConcurrentSyncs < 0 {
+			return nil, fmt.Errorf("invalid database config %s: max_concurrent_syncs cannot be negative", db.Name)
+		}
+		for _, r := range db.Masking {
+			if err := r.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid database config %s: %w", db.Name, err)
+			}
+		}
+	}
+
+	if cfg.Scheduler.MaxConcurrentSyncs < 0 {
+		return nil, errors.New("scheduler max_concurrent_syncs cannot be negative")
+	}
+	if cfg.Scheduler.ShutdownTimeout < 0 {
+		return nil, errors.New("scheduler shutdown_timeout cannot be negative")
+	}
+	if cfg.Leader != nil {
+		if err := cfg.Leader.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid leader config: %w", err)
+		}
+	}
+
 	// Валидируем все конфиги синхронизации
 	for _, syncCfg := range cfg.Sync {
 		if err := syncCfg.Validate(); err != nil {
 			return nil, fmt.Errorf("invalid sync config: %w", err)
 		}
 	}
+	if err := validateDependencies(cfg.Sync); err != nil {
+		return nil, fmt.Errorf("invalid depends_on: %w", err)
+	}
 
 	return &cfg, nil
 }
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDependencies(t *testing.T) {
+	table := func(name string, dependsOn ...string) TableSyncConfig {
+		var t TableSyncConfig
+		t.Target.Table = name
+		t.DependsOn = dependsOn
+		return t
+	}
+	syncOf := func(tables ...TableSyncConfig) SyncConfig {
+		return SyncConfig{Tables: tables}
+	}
+
+	tests := []struct {
+		name    string
+		syncs   []SyncConfig
+		wantErr string // Пусто - конфиг корректен
+	}{
+		{"chain", []SyncConfig{syncOf(table("sims"), table("all_imsi", "sims"), table("report", "all_imsi"))}, ""},
+		{"across sync sections", []SyncConfig{syncOf(table("sims")), syncOf(table("report", "sims"))}, ""},
+		{"diamond", []SyncConfig{syncOf(table("a"), table("b", "a"), table("c", "a"), table("d", "b", "c"))}, ""},
+		{"unknown table", []SyncConfig{syncOf(table("report", "sims"))}, "unknown table sims"},
+		{"ambiguous table", []SyncConfig{syncOf(table("sims")), syncOf(table("sims"), table("report", "sims"))}, "ambiguous table sims"},
+		{"self cycle", []SyncConfig{syncOf(table("sims", "sims"))}, "dependency cycle: sims -> sims"},
+		{"cycle", []SyncConfig{syncOf(table("a", "c"), table("b", "a"), table("c", "b"))}, "dependency cycle: a -> c -> b -> a"},
+		{"cycle across sync sections", []SyncConfig{syncOf(table("a", "b")), syncOf(table("b", "a"))}, "dependency cycle"},
+		{"cycle below valid table", []SyncConfig{syncOf(table("a"), table("b", "a", "c"), table("c", "b"))}, "dependency cycle: b -> c -> b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDependencies(tt.syncs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateDependencies: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateDependencies = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package connectors
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	backupInfix      = "_bk_"
+	backupTimeLayout = "20060102150405"
+	// Длина хэша, заменяющего хвост слишком длинного имени таблицы
+	backupHashLength = 4
+)
+
+// Максимальная длина имени таблицы. В Oracle до 12.2 имя ограничено 30 байтами,
+// ограничение 12.2+ (128) не используется, чтобы копии работали на любой версии
+var backupNameLimits = map[string]int{
+	DialectOracle:  30,
+	DialectMariaDB: 64,
+}
+
+// Backup - резервная копия таблицы, оставшаяся после SwapTables
+type Backup struct {
+	Table     string    // Имя таблицы резервной копии
+	CreatedAt time.Time // Время замены таблицы
+}
+
+// backupPrefixes возвращает префиксы имен резервных копий table. Если имя копии не
+// помещается в ограничение БД, начало имени таблицы дополняется хэшем полного имени;
+// прежний полный префикс тоже возвращается, чтобы находились копии, созданные до сокращения
+func backupPrefixes(table string, limit int) []string {
+	owner, name := splitOracleName(table)
+	full := table + backupInfix
+	if len(name)+len(backupInfix)+len(backupTimeLayout) <= limit {
+		return []string{full}
+	}
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToUpper(name)))
+	keep := limit - len(backupInfix) - len(backupTimeLayout) - backupHashLength
+	short := fmt.Sprintf("%s%0*x", name[:keep], backupHashLength, h.Sum32()&0xffff)
+	return []string{qualifyOracleName(owner, short) + backupInfix, full}
+}
+
+// NewBackupName возвращает свободное имя резервной копии table на текущий момент.
+// Если копия с этой отметкой времени уже есть (две замены за одну секунду),
+// отметка сдвигается на секунду вперед
+func NewBackupName(conn DatabaseConnector, table string) (string, error) {
+	prefix := backupPrefixes(table, backupNameLimits[Dialect(conn)])[0]
+	existing, err := conn.ListTables(prefix)
+	if err != nil {
+		return "", fmt.Errorf("list backups of %s failed: %w", table, err)
+	}
+	for at := time.Now(); ; at = at.Add(time.Second) {
+		name := prefix + at.Format(backupTimeLayout)
+		if !containsFold(existing, name) {
+			return name, nil
+		}
+	}
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBackupTable проверяет, что name - резервная копия с префиксом prefix, и возвращает время ее создания
+func parseBackupTable(prefix, name string) (time.Time, bool) {
+	if len(name) != len(prefix)+len(backupTimeLayout) || !strings.EqualFold(name[:len(prefix)], prefix) {
+		return time.Time{}, false
+	}
+	at, err := time.ParseInLocation(backupTimeLayout, name[len(prefix):], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+// ListBackups возвращает резервные копии таблицы, начиная с самой новой
+func ListBackups(conn DatabaseConnector, table string) ([]Backup, error) {
+	var backups []Backup
+	for _, prefix := range backupPrefixes(table, backupNameLimits[Dialect(conn)]) {
+		names, err := conn.ListTables(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("list backups of %s failed: %w", table, err)
+		}
+		for _, name := range names {
+			if at, ok := parseBackupTable(prefix, name); ok {
+				backups = append(backups, Backup{Table: name, CreatedAt: at})
+			}
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// PruneBackups удаляет резервные копии сверх keep самых новых и старше maxAge (0 - без ограничения).
+// Возвращает имена удаленных таблиц
+func PruneBackups(conn DatabaseConnector, table string, keep int, maxAge time.Duration) ([]string, error) {
+	backups, err := ListBackups(conn, table)
+	if err != nil {
+		return nil, err
+	}
+	var dropped []string
+	for i, b := range backups {
+		expired := maxAge > 0 && time.Since(b.CreatedAt) > maxAge
+		if i < keep && !expired {
+			continue
+		}
+		if err := conn.DropTable(b.Table); err != nil {
+			return dropped, fmt.Errorf("drop backup %s failed: %w", b.Table, err)
+		}
+		dropped = append(dropped, b.Table)
+	}
+	return dropped, nil
+}
+
+// likePrefix экранирует символы шаблона LIKE и добавляет % в конец
+func likePrefix(prefix string, escape rune) string {
+	var b strings.Builder
+	for _, r := range prefix {
+		if r == '%' || r == '_' || r == escape {
+			b.WriteRune(escape)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('%')
+	return b.String()
+}
+
+// FindBackup возвращает резервную копию по имени или самую новую, если имя пустое
+func FindBackup(conn DatabaseConnector, table, name string) (Backup, error) {
+	backups, err := ListBackups(conn, table)
+	if err != nil {
+		return Backup{}, err
+	}
+	if len(backups) == 0 {
+		return Backup{}, fmt.Errorf("no backups of %s found", table)
+	}
+	if name == "" {
+		return backups[0], nil
+	}
+	for _, b := range backups {
+		if strings.EqualFold(b.Table, name) {
+			return b, nil
+		}
+	}
+	return Backup{}, fmt.Errorf("backup %s of %s not found", name, table)
+}
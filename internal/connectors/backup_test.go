@@ -0,0 +1,45 @@
+package connectors
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackupPrefixes(t *testing.T) {
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.Local)
+	tests := []struct {
+		name  string
+		table string
+		limit int
+		count int
+	}{
+		{"short name", "sims", 30, 1},
+		{"exact limit", "SIMS_ARCHIVE", 30, 1},
+		{"oracle long name", "SIMS_SUBSCRIBER_HISTORY", 30, 2},
+		{"qualified long name", "APP.SIMS_SUBSCRIBER_HISTORY", 30, 2},
+		{"mariadb long name", "sims_subscriber_history", 64, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefixes := backupPrefixes(tt.table, tt.limit)
+			if len(prefixes) != tt.count {
+				t.Fatalf("backupPrefixes(%q) = %v, want %d prefixes", tt.table, prefixes, tt.count)
+			}
+			name := prefixes[0] + at.Format(backupTimeLayout)
+			if _, short := splitOracleName(name); len(short) > tt.limit {
+				t.Errorf("backup name %q exceeds %d", short, tt.limit)
+			}
+			got, ok := parseBackupTable(prefixes[0], strings.ToUpper(name))
+			if !ok || !got.Equal(at) {
+				t.Errorf("parseBackupTable(%q) = %v, %v", name, got, ok)
+			}
+		})
+	}
+
+	a := backupPrefixes("SIMS_SUBSCRIBER_HISTORY", 30)[0]
+	b := backupPrefixes("SIMS_SUBSCRIBER_PROFILE", 30)[0]
+	if a == b {
+		t.Errorf("tables with a common start share backup prefix %q", a)
+	}
+}
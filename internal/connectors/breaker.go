@@ -0,0 +1,297 @@
+package connectors
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/domain"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen - запрос не выполнялся, так как БД считается недоступной
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Состояния автоматического выключателя
+const (
+	BreakerClosed   = "closed"    // Запросы выполняются
+	BreakerOpen     = "open"      // Запросы отклоняются без обращения к БД
+	BreakerHalfOpen = "half_open" // Истек open_timeout, следующий запрос проверяет БД через Ping
+)
+
+// BreakerStatus - состояние выключателя для статуса
+type BreakerStatus struct {
+	Connection string    `json:"connection"`
+	State      string    `json:"state"`
+	Failures   int       `json:"failures"`
+	OpenedAt   time.Time `json:"opened_at,omitempty"`
+	LastError  string    `json:"last_error,omitempty"`
+}
+
+// BreakerConnector - автоматический выключатель вокруг коннектора. После
+// failures подряд ошибок соединения он размыкается, и запросы сразу получают
+// ErrCircuitOpen. Через open_timeout первый запрос проверяет БД через Ping:
+// при успехе выключатель замыкается, иначе снова размыкается
+type BreakerConnector struct {
+	DatabaseConnector
+	name        string
+	failures    int
+	openTimeout time.Duration
+	onChange    func(name, state string, err error)
+
+	mu       sync.Mutex
+	state    string
+	count    int // Ошибки соединения подряд
+	openedAt time.Time
+	lastErr  error
+	probing  bool
+}
+
+// NewBreaker оборачивает коннектор выключателем. onChange (может быть nil)
+// вызывается при смене состояния
+func NewBreaker(name string, conn DatabaseConnector, cfg *config.BreakerConfig, onChange func(name, state string, err error)) *BreakerConnector {
+	b := &BreakerConnector{
+		DatabaseConnector: conn,
+		name:              name,
+		failures:          cfg.FailureThreshold(),
+		openTimeout:       cfg.ResetTimeout(),
+		onChange:          onChange,
+		state:             BreakerClosed,
+	}
+	return b
+}
+
+// Unwrap возвращает исходный коннектор
+func (b *BreakerConnector) Unwrap() DatabaseConnector {
+	return b.DatabaseConnector
+}
+
+// Unwrap возвращает коннектор без оберток, например для проверки его типа
+func Unwrap(conn DatabaseConnector) DatabaseConnector {
+	for {
+		w, ok := conn.(interface{ Unwrap() DatabaseConnector })
+		if !ok {
+			return conn
+		}
+		conn = w.Unwrap()
+	}
+}
+
+// Status возвращает состояние выключателя
+func (b *BreakerConnector) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := BreakerStatus{Connection: b.name, State: b.currentState(), Failures: b.count, OpenedAt: b.openedAt}
+	if b.lastErr != nil {
+		st.LastError = b.lastErr.Error()
+	}
+	return st
+}
+
+func (b *BreakerConnector) currentState() string {
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.openTimeout {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow решает, можно ли выполнить запрос. В полуоткрытом состоянии один
+// вызывающий проверяет БД через Ping, остальные получают ErrCircuitOpen
+func (b *BreakerConnector) allow() error {
+	b.mu.Lock()
+	switch b.currentState() {
+	case BreakerClosed:
+		b.mu.Unlock()
+		return nil
+	case BreakerOpen:
+		b.mu.Unlock()
+		return fmt.Errorf("%s: %w", b.name, ErrCircuitOpen)
+	}
+	if b.probing {
+		b.mu.Unlock()
+		return fmt.Errorf("%s: %w", b.name, ErrCircuitOpen)
+	}
+	b.probing = true
+	b.mu.Unlock()
+
+	err := b.DatabaseConnector.Ping()
+
+	b.mu.Lock()
+	b.probing = false
+	if err != nil {
+		b.lastErr = err
+		b.setState(BreakerOpen, err)
+		b.mu.Unlock()
+		return fmt.Errorf("%s: probe failed: %w", b.name, ErrCircuitOpen)
+	}
+	b.count = 0
+	b.setState(BreakerClosed, nil)
+	b.mu.Unlock()
+	return nil
+}
+
+// record учитывает результат запроса. Размыкают выключатель только ошибки
+// соединения: ошибки данных и взаимоблокировки не говорят о недоступности БД
+func (b *BreakerConnector) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil || Classify(err) != ErrorConnection {
+		b.count = 0
+		return
+	}
+	b.count++
+	b.lastErr = err
+	if b.state == BreakerClosed && b.count >= b.failures {
+		b.setState(BreakerOpen, err)
+	}
+}
+
+// setState вызывается под b.mu
+func (b *BreakerConnector) setState(state string, err error) {
+	if state == BreakerOpen {
+		b.openedAt = time.Now()
+	}
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(b.name, state, err)
+	}
+}
+
+func (b *BreakerConnector) call(op func() error) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := op()
+	b.record(err)
+	return err
+}
+
+func (b *BreakerConnector) GetCount(ctx context.Context, schema *domain.TableSchema) (count int, err error) {
+	err = b.call(func() error {
+		count, err = b.DatabaseConnector.GetCount(ctx, schema)
+		return err
+	})
+	return count, err
+}
+
+func (b *BreakerConnector) GetBatch(ctx context.Context, tableName string, offset int, batchSize int, schema *domain.TableSchema) (records []domain.Record, err error) {
+	err = b.call(func() error {
+		records, err = b.DatabaseConnector.GetBatch(ctx, tableName, offset, batchSize, schema)
+		return err
+	})
+	return records, err
+}
+
+func (b *BreakerConnector) CreateTempTable(originalTable, tempTable string, schema *domain.TableSchema) error {
+	return b.call(func() error {
+		return b.DatabaseConnector.CreateTempTable(originalTable, tempTable, schema)
+	})
+}
+
+func (b *BreakerConnector) InsertBatch(ctx context.Context, tableName string, records []domain.Record, columns []string) error {
+	return b.call(func() error {
+		return b.DatabaseConnector.InsertBatch(ctx, tableName, records, columns)
+	})
+}
+
+func (b *BreakerConnector) BuildIndexes(ctx context.Context, tableName string, schema *domain.TableSchema, progress func(done, total int, index string)) error {
+	return b.call(func() error {
+		return b.DatabaseConnector.BuildIndexes(ctx, tableName, schema, progress)
+	})
+}
+
+func (b *BreakerConnector) AnalyzeTable(ctx context.Context, tableName string) error {
+	return b.call(func() error {
+		return b.DatabaseConnector.AnalyzeTable(ctx, tableName)
+	})
+}
+
+func (b *BreakerConnector) SwapTables(originalTable, tempTable string) error {
+	return b.call(func() error {
+		return b.DatabaseConnector.SwapTables(originalTable, tempTable)
+	})
+}
+
+func (b *BreakerConnector) DropTable(tableName string) error {
+	return b.call(func() error {
+		return b.DatabaseConnector.DropTable(tableName)
+	})
+}
+
+func (b *BreakerConnector) CreateTableIfNotExists(tableName string, schema *domain.TableSchema) error {
+	return b.call(func() error {
+		return b.DatabaseConnector.CreateTableIfNotExists(tableName, schema)
+	})
+}
+
+func (b *BreakerConnector) ListTables(prefix string) (tables []string, err error) {
+	err = b.call(func() error {
+		tables, err = b.DatabaseConnector.ListTables(prefix)
+		return err
+	})
+	return tables, err
+}
+
+func (b *BreakerConnector) PublishedTable(name string) (table string, err error) {
+	err = b.call(func() error {
+		table, err = b.DatabaseConnector.PublishedTable(name)
+		return err
+	})
+	return table, err
+}
+
+func (b *BreakerConnector) PublishTable(name, table string) error {
+	return b.call(func() error {
+		return b.DatabaseConnector.PublishTable(name, table)
+	})
+}
+
+func (b *BreakerConnector) CopyGrants(fromTable, toTable string) error {
+	return b.call(func() error {
+		return b.DatabaseConnector.CopyGrants(fromTable, toTable)
+	})
+}
+
+func (b *BreakerConnector) CloneMetadata(ctx context.Context, fromTable, toTable string) (report *MetadataReport, err error) {
+	err = b.call(func() error {
+		report, err = b.DatabaseConnector.CloneMetadata(ctx, fromTable, toTable)
+		return err
+	})
+	return report, err
+}
+
+func (b *BreakerConnector) AcquireLock(name string, timeout time.Duration) (lock *Lock, err error) {
+	err = b.call(func() error {
+		lock, err = b.DatabaseConnector.AcquireLock(name, timeout)
+		return err
+	})
+	return lock, err
+}
+
+func (b *BreakerConnector) ExecuteProcedure(procName string, args ...interface{}) (n int, err error) {
+	err = b.call(func() error {
+		n, err = b.DatabaseConnector.ExecuteProcedure(procName, args...)
+		return err
+	})
+	return n, err
+}
+
+func (b *BreakerConnector) ExecuteSelect(ctx context.Context, query string, args ...interface{}) (records []domain.Record, err error) {
+	err = b.call(func() error {
+		records, err = b.DatabaseConnector.ExecuteSelect(ctx, query, args...)
+		return err
+	})
+	return records, err
+}
+
+func (b *BreakerConnector) ExecuteSelectWithSchema(query string, args ...interface{}) (schema *domain.TableSchema, err error) {
+	err = b.call(func() error {
+		schema, err = b.DatabaseConnector.ExecuteSelectWithSchema(query, args...)
+		return err
+	})
+	return schema, err
+}
@@ -1,9 +1,14 @@
 package connectors
 
 import (
+	"context"
 	"db_swapper/internal/domain"
+	"fmt"
+	"time"
 )
 
+// DatabaseConnector - подключение к БД. Методы с ctx прерывают выполняемый запрос
+// при отмене ctx (например, при аварийной остановке синхронизации)
 type DatabaseConnector interface {
 	// Функции по умолчанию
 	Connect() error
@@ -11,19 +16,64 @@ type DatabaseConnector interface {
 	Disconnect() error
 
 	// Функции с пачками
-	GetCount(schema *domain.TableSchema) (int, error)
-	GetBatch(tableName string, offset int, batchSize int, schema *domain.TableSchema) ([]domain.Record, error)
+	GetCount(ctx context.Context, schema *domain.TableSchema) (int, error)
+	GetBatch(ctx context.Context, tableName string, offset int, batchSize int, schema *domain.TableSchema) ([]domain.Record, error)
 	CreateTempTable(originalTable, tempTable string, schema *domain.TableSchema) error
-	InsertBatch(tableName string, records []domain.Record, columns []string) error
+	InsertBatch(ctx context.Context, tableName string, records []domain.Record, columns []string) error
+	// Создает первичный ключ и индексы схемы на заполненной таблице, вызывая progress после каждого
+	BuildIndexes(ctx context.Context, tableName string, schema *domain.TableSchema, progress func(done, total int, index string)) error
+	// Обновляет статистику таблицы
+	AnalyzeTable(ctx context.Context, tableName string) error
 
 	// Функции с участием временных таблиц
 	SwapTables(originalTable, tempTable string) error
 	DropTable(tableName string) error
+	// Создает служебную таблицу (отказы, управление), если ее еще нет
+	CreateTableIfNotExists(tableName string, schema *domain.TableSchema) error
+	// Возвращает имена таблиц текущей схемы, начинающихся с prefix
+	ListTables(prefix string) ([]string, error)
+
+	// Публикация через представление (MariaDB) или синоним (Oracle)
+	// Возвращает таблицу, на которую указывает name, или "", если name не представление/синоним
+	PublishedTable(name string) (string, error)
+	// Атомарно переключает name на table. Если name - обычная таблица, она сохраняется как резервная копия
+	PublishTable(name, table string) error
+	// Выдает на toTable те же права, что выданы на fromTable
+	CopyGrants(fromTable, toTable string) error
+	// Переносит внешние ключи, CHECK-ограничения, триггеры, комментарии и права с fromTable на toTable
+	CloneMetadata(ctx context.Context, fromTable, toTable string) (*MetadataReport, error)
+	// Захватывает именованную блокировку в отдельной сессии. Если за timeout не удалось - ErrLockBusy
+	AcquireLock(name string, timeout time.Duration) (*Lock, error)
+	// Захватывает или продлевает аренду name в служебной таблице (см. EnsureLeaseTable)
+	AcquireLease(table, name, holder string, ttl time.Duration) (*Lease, error)
+	ReleaseLease(table, name, holder string) error
 
 	// Для процедур
 	ExecuteProcedure(procName string, args ...interface{}) (int, error)
 	// Если хотим использовать SELECT query and return []records
-	ExecuteSelect(query string, args ...interface{}) ([]domain.Record, error)
+	ExecuteSelect(ctx context.Context, query string, args ...interface{}) ([]domain.Record, error)
 	// Если хотим после выборки вернуть схему таблицы SELECT query and return table schema (create temp table for this schema)
 	ExecuteSelectWithSchema(query string, args ...interface{}) (*domain.TableSchema, error)
 }
+
+// Диалекты SQL
+const (
+	DialectOracle  = "oracle"
+	DialectMariaDB = "mariadb"
+)
+
+// Dialect возвращает диалект SQL коннектора
+func Dialect(conn DatabaseConnector) string {
+	if _, ok := Unwrap(conn).(*OracleConnector); ok {
+		return DialectOracle
+	}
+	return DialectMariaDB
+}
+
+// Placeholder возвращает параметр запроса с номером n (начиная с 1) в диалекте коннектора
+func Placeholder(conn DatabaseConnector, n int) string {
+	if Dialect(conn) == DialectOracle {
+		return fmt.Sprintf(":%d", n)
+	}
+	return "?"
+}
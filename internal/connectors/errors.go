@@ -0,0 +1,148 @@
+package connectors
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"syscall"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrorClass - класс ошибки БД для решения о повторе
+type ErrorClass int
+
+const (
+	ErrorPermanent  ErrorClass = iota // Повтор не поможет: синтаксис, права, ограничения, данные
+	ErrorTransient                    // Временная ошибка: взаимоблокировка, таймаут блокировки, перегрузка
+	ErrorConnection                   // Потеря соединения: повтор после переподключения
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorTransient:
+		return "transient"
+	case ErrorConnection:
+		return "connection"
+	default:
+		return "permanent"
+	}
+}
+
+// Коды MariaDB
+var mariaDBErrorClasses = map[uint16]ErrorClass{
+	1205: ErrorTransient,  // Lock wait timeout exceeded
+	1213: ErrorTransient,  // Deadlock found
+	1040: ErrorTransient,  // Too many connections
+	1203: ErrorTransient,  // User has more than max_user_connections
+	1927: ErrorConnection, // Connection was killed
+	2002: ErrorConnection, // Can't connect through socket
+	2003: ErrorConnection, // Can't connect to server
+	2006: ErrorConnection, // Server has gone away
+	2013: ErrorConnection, // Lost connection during query
+}
+
+// Коды Oracle (ORA-NNNNN)
+var oracleErrorClasses = map[int]ErrorClass{
+	60:    ErrorTransient,  // Deadlock detected
+	54:    ErrorTransient,  // Resource busy (NOWAIT)
+	4021:  ErrorTransient,  // Timeout waiting for lock
+	4068:  ErrorTransient,  // Existing state of packages discarded
+	1033:  ErrorConnection, // Initialization or shutdown in progress
+	1034:  ErrorConnection, // Oracle not available
+	1089:  ErrorConnection, // Immediate shutdown in progress
+	1092:  ErrorConnection, // Instance terminated
+	3113:  ErrorConnection, // End-of-file on communication channel
+	3114:  ErrorConnection, // Not connected to Oracle
+	3135:  ErrorConnection, // Connection lost contact
+	12170: ErrorConnection, // Connect timeout
+	12514: ErrorConnection, // Listener does not know of service
+	12528: ErrorConnection, // Listener: all instances blocking
+	12537: ErrorConnection, // TNS: connection closed
+	12541: ErrorConnection, // TNS: no listener
+	12547: ErrorConnection, // TNS: lost contact
+	25408: ErrorConnection, // Can not safely replay call
+}
+
+var oraCodeRe = regexp.MustCompile(`ORA-(\d{5})`)
+
+// permanentError помечает ошибку, которую нельзя повторять, даже если она выглядит временной
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку как неповторяемую (например, обрыв на COMMIT,
+// после которого неизвестно, сохранены ли данные)
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Classify определяет класс ошибки по коду MariaDB/Oracle и сетевым ошибкам
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ErrorPermanent
+	}
+	var perm *permanentError
+	if errors.As(err, &perm) || errors.Is(err, context.Canceled) {
+		return ErrorPermanent
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		if class, ok := mariaDBErrorClasses[mysqlErr.Number]; ok {
+			return class
+		}
+		return ErrorPermanent
+	}
+	if m := oraCodeRe.FindStringSubmatch(err.Error()); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		if class, ok := oracleErrorClasses[code]; ok {
+			return class
+		}
+		return ErrorPermanent
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return ErrorConnection
+	}
+	// context.DeadlineExceeded реализует net.Error, поэтому проверяется раньше:
+	// истекший таймаут запроса не означает потерю соединения
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTransient
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorConnection
+	}
+	return ErrorPermanent
+}
+
+// IsTransient сообщает, что операцию имеет смысл повторить
+func IsTransient(err error) bool {
+	return Classify(err) != ErrorPermanent
+}
+
+// IsDataError сообщает, что запрос отклонен из-за самих данных (ограничения, типы,
+// длина значений): такую пачку имеет смысл делить, чтобы отбросить плохие строки.
+// Ошибки соединения, временные ошибки, ошибки COMMIT (данные могли сохраниться)
+// и разомкнутый выключатель к ним не относятся
+func IsDataError(err error) bool {
+	var perm *permanentError
+	if err == nil || errors.As(err, &perm) || errors.Is(err, ErrCircuitOpen) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return Classify(err) == ErrorPermanent
+}
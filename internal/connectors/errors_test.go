@@ -0,0 +1,43 @@
+package connectors
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestClassify(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"deadline exceeded", context.DeadlineExceeded, ErrorTransient},
+		{"wrapped deadline", fmt.Errorf("get batch failed: %w", ctx.Err()), ErrorTransient},
+		{"canceled", fmt.Errorf("insert failed: %w", context.Canceled), ErrorPermanent},
+		{"net timeout", &net.OpError{Op: "read", Err: errors.New("i/o timeout")}, ErrorConnection},
+		{"bad conn", driver.ErrBadConn, ErrorConnection},
+		{"mariadb deadlock", &mysql.MySQLError{Number: 1213}, ErrorTransient},
+		{"mariadb duplicate", &mysql.MySQLError{Number: 1062}, ErrorPermanent},
+		{"oracle lost contact", errors.New("ORA-03135: connection lost contact"), ErrorConnection},
+		{"oracle constraint", errors.New("ORA-00001: unique constraint violated"), ErrorPermanent},
+		{"permanent commit", Permanent(driver.ErrBadConn), ErrorPermanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package connectors
+
+import (
+	"database/sql"
+	"db_swapper/internal/domain"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Lease - аренда (например, лидерства) в служебной таблице. Время - по часам БД в UTC
+type Lease struct {
+	Name      string
+	Holder    string
+	ExpiresAt time.Time
+}
+
+// HeldBy сообщает, что аренда действует и принадлежит holder
+func (l *Lease) HeldBy(holder string) bool {
+	return l != nil && l.Holder == holder
+}
+
+// EnsureLeaseTable создает служебную таблицу аренды, если ее еще нет
+func EnsureLeaseTable(conn DatabaseConnector, table string) error {
+	textType, timeType := "VARCHAR(255)", "DATETIME(6)"
+	if Dialect(conn) == DialectOracle {
+		textType, timeType = "VARCHAR2(255)", "TIMESTAMP"
+	}
+	schema := &domain.TableSchema{
+		Columns: []domain.ColumnInfo{
+			{Name: "lease_name", DataType: textType},
+			{Name: "holder", DataType: textType},
+			{Name: "expires_at", DataType: timeType},
+			{Name: "renewed_at", DataType: timeType},
+		},
+		PrimaryKey: "lease_name",
+	}
+	if err := conn.CreateTableIfNotExists(table, schema); err != nil {
+		return fmt.Errorf("create lease table %s failed: %w", table, err)
+	}
+	return nil
+}
+
+// AcquireLease захватывает свободную или истекшую аренду либо продлевает свою
+// на ttl и возвращает текущее состояние аренды
+func (m *MariaDBConnector) AcquireLease(table, name, holder string, ttl time.Duration) (*Lease, error) {
+	// Присваивания ON DUPLICATE KEY UPDATE выполняются слева направо, поэтому
+	// после смены holder остальные колонки обновляются по тому же условию
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (lease_name, holder, expires_at, renewed_at)
+		VALUES (?, ?, UTC_TIMESTAMP(6) + INTERVAL ? MICROSECOND, UTC_TIMESTAMP(6))
+		ON DUPLICATE KEY UPDATE
+			holder = IF(holder = VALUES(holder) OR expires_at < UTC_TIMESTAMP(6), VALUES(holder), holder),
+			expires_at = IF(holder = VALUES(holder), VALUES(expires_at), expires_at),
+			renewed_at = IF(holder = VALUES(holder), VALUES(renewed_at), renewed_at)`, table)
+	if _, err := m.db.Exec(query, name, holder, ttl.Microseconds()); err != nil {
+		return nil, fmt.Errorf("acquire lease %s failed: %w", name, err)
+	}
+	return readLease(m.db, fmt.Sprintf("SELECT holder, expires_at FROM %s WHERE lease_name = ?", table), name)
+}
+
+// ReleaseLease освобождает аренду, если она принадлежит holder
+func (m *MariaDBConnector) ReleaseLease(table, name, holder string) error {
+	query := fmt.Sprintf("UPDATE %s SET expires_at = UTC_TIMESTAMP(6) WHERE lease_name = ? AND holder = ?", table)
+	if _, err := m.db.Exec(query, name, holder); err != nil {
+		return fmt.Errorf("release lease %s failed: %w", name, err)
+	}
+	return nil
+}
+
+// AcquireLease захватывает свободную или истекшую аренду либо продлевает свою
+// на ttl и возвращает текущее состояние аренды
+func (o *OracleConnector) AcquireLease(table, name, holder string, ttl time.Duration) (*Lease, error) {
+	query := fmt.Sprintf(`
+		MERGE INTO %s l
+		USING (SELECT :1 AS lease_name, :2 AS holder,
+		              SYS_EXTRACT_UTC(SYSTIMESTAMP) AS now_utc,
+		              NUMTODSINTERVAL(:3, 'SECOND') AS ttl FROM dual) s
+		   ON (l.lease_name = s.lease_name)
+		 WHEN MATCHED THEN UPDATE
+		      SET l.holder = s.holder, l.expires_at = s.now_utc + s.ttl, l.renewed_at = s.now_utc
+		    WHERE l.holder = s.holder OR l.expires_at < s.now_utc
+		 WHEN NOT MATCHED THEN INSERT (lease_name, holder, expires_at, renewed_at)
+		      VALUES (s.lease_name, s.holder, s.now_utc + s.ttl, s.now_utc)`, table)
+	// ORA-00001: строку одновременно вставил другой экземпляр, аренда у него
+	if _, err := o.db.Exec(query, name, holder, ttl.Seconds()); err != nil && !strings.Contains(err.Error(), "ORA-00001") {
+		return nil, fmt.Errorf("acquire lease %s failed: %w", name, err)
+	}
+	return readLease(o.db, fmt.Sprintf("SELECT holder, expires_at FROM %s WHERE lease_name = :1", table), name)
+}
+
+// ReleaseLease освобождает аренду, если она принадлежит holder
+func (o *OracleConnector) ReleaseLease(table, name, holder string) error {
+	query := fmt.Sprintf("UPDATE %s SET expires_at = SYS_EXTRACT_UTC(SYSTIMESTAMP) WHERE lease_name = :1 AND holder = :2", table)
+	if _, err := o.db.Exec(query, name, holder); err != nil {
+		return fmt.Errorf("release lease %s failed: %w", name, err)
+	}
+	return nil
+}
+
+func readLease(db *sql.DB, query, name string) (*Lease, error) {
+	lease := &Lease{Name: name}
+	var expires time.Time
+	if err := db.QueryRow(query, name).Scan(&lease.Holder, &expires); err != nil {
+		return nil, fmt.Errorf("read lease %s failed: %w", name, err)
+	}
+	// Колонка хранит UTC без пояса
+	lease.ExpiresAt = time.Date(expires.Year(), expires.Month(), expires.Day(),
+		expires.Hour(), expires.Minute(), expires.Second(), expires.Nanosecond(), time.UTC)
+	return lease, nil
+}
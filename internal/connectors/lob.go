@@ -0,0 +1,146 @@
+package connectors
+
+import (
+	"db_swapper/internal/domain"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultLOBChunkSize - размер значения, начиная с которого LOB пишется частями
+const defaultLOBChunkSize = 1 << 20
+
+// lobColumn описывает колонку с лимитом размера в запросе GetBatch
+type lobColumn struct {
+	name     string
+	dataType string
+	lenAlias string
+	limit    domain.LOBLimit
+	chunked  bool // Значение сверх лимита не читается запросом, а дочитывается частями
+}
+
+// lobDialect формирует диалектные выражения для работы с LOB
+type lobDialect struct {
+	// length возвращает выражение длины значения колонки
+	length func(col string) string
+	// truncate возвращает выражение, обрезающее значение на сервере.
+	// Пустая строка означает, что запрос не может обрезать значение: превышающее
+	// лимит значение не читается, а дочитывается коннектором частями (lobColumn.chunked)
+	truncate func(col domain.ColumnInfo, max int64) string
+}
+
+// buildLOBSelect строит список выражений SELECT с учетом лимитов LOB.
+// Значения, превышающие лимит при политиках skip_row/fail (и при truncate, если
+// диалект не обрезает значение в запросе), не читаются из БД: вместо них
+// возвращается NULL, а размер приходит отдельной колонкой.
+// names содержит имена итоговых колонок (для внешнего SELECT в Oracle)
+func buildLOBSelect(schema *domain.TableSchema, d lobDialect) (exprs []string, names []string, lobs []lobColumn) {
+	for i, col := range schema.Columns {
+		if col.LOBLimit == nil || col.LOBLimit.MaxSize <= 0 {
+			exprs = append(exprs, col.Name)
+			names = append(names, col.Name)
+			continue
+		}
+
+		lc := lobColumn{
+			name:     col.Name,
+			dataType: col.DataType,
+			lenAlias: fmt.Sprintf("LOBLEN_%d", i),
+			limit:    *col.LOBLimit,
+		}
+		lengthExpr := d.length(col.Name)
+
+		var valueExpr string
+		if lc.limit.OnExceed == domain.LOBTruncate {
+			valueExpr = d.truncate(col, lc.limit.MaxSize)
+			lc.chunked = valueExpr == ""
+		}
+		if valueExpr != "" {
+			valueExpr = fmt.Sprintf("%s AS %s", valueExpr, col.Name)
+		} else {
+			valueExpr = fmt.Sprintf("CASE WHEN %s > %d THEN NULL ELSE %s END AS %s",
+				lengthExpr, lc.limit.MaxSize, col.Name, col.Name)
+		}
+
+		exprs = append(exprs, valueExpr, fmt.Sprintf("%s AS %s", lengthExpr, lc.lenAlias))
+		names = append(names, col.Name, lc.lenAlias)
+		lobs = append(lobs, lc)
+	}
+	return exprs, names, lobs
+}
+
+// applyLOBLimits применяет политику превышения размера к прочитанной записи
+// и удаляет из нее служебные колонки длины. Возвращает колонки, значения которых
+// превышают лимит и должны быть дочитаны частями (lobColumn.chunked)
+func applyLOBLimits(record domain.Record, lobs []lobColumn) (chunked []lobColumn, err error) {
+	for _, lc := range lobs {
+		size := findValue(record, lc.lenAlias)
+		deleteValue(record, lc.lenAlias)
+
+		length, ok := int64Of(size)
+		if !ok || length <= lc.limit.MaxSize {
+			continue
+		}
+
+		key := recordKey(record, lc.name)
+		switch {
+		case lc.chunked:
+			chunked = append(chunked, lc)
+		case lc.limit.OnExceed == domain.LOBTruncate:
+			record[key] = domain.TruncateLOB(record[key], lc.limit.MaxSize)
+		case lc.limit.OnExceed == domain.LOBSkipRow:
+			record[key] = domain.OversizedValue{Column: lc.name, Size: length}
+		default:
+			return nil, fmt.Errorf("column %s: %w (%d > %d)", lc.name, domain.ErrLOBTooLarge, length, lc.limit.MaxSize)
+		}
+	}
+	return chunked, nil
+}
+
+// isLargeValue сообщает, что значение стоит передавать в БД частями
+func isLargeValue(v interface{}, chunkSize int) bool {
+	switch val := v.(type) {
+	case []byte:
+		return len(val) >= chunkSize
+	case string:
+		return len(val) >= chunkSize
+	}
+	return false
+}
+
+// recordKey находит имя колонки в записи без учета регистра
+func recordKey(record domain.Record, name string) string {
+	if _, ok := record[name]; ok {
+		return name
+	}
+	for k := range record {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return name
+}
+
+func findValue(record domain.Record, name string) interface{} {
+	return record[recordKey(record, name)]
+}
+
+func deleteValue(record domain.Record, name string) {
+	delete(record, recordKey(record, name))
+}
+
+func int64Of(v interface{}) (int64, bool) {
+	switch val := v.(type) {
+	case int64:
+		return val, true
+	case float64:
+		return int64(val), true
+	case domain.Decimal:
+		n, err := strconv.ParseInt(val.String(), 10, 64)
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseInt(val, 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,143 @@
+package connectors
+
+import (
+	"db_swapper/internal/domain"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildLOBSelect(t *testing.T) {
+	column := func(name, dataType string, max int64, onExceed string) domain.ColumnInfo {
+		return domain.ColumnInfo{Name: name, DataType: dataType, LOBLimit: &domain.LOBLimit{MaxSize: max, OnExceed: onExceed}}
+	}
+	tests := []struct {
+		name        string
+		dialect     lobDialect
+		column      domain.ColumnInfo
+		wantValue   string
+		wantChunked bool
+	}{
+		{"oracle clob within sql", oracleLOBDialect, column("NOTES", "CLOB", 1000, domain.LOBTruncate),
+			"DBMS_LOB.SUBSTR(NOTES, 1000, 1) AS NOTES", false},
+		{"oracle blob within sql", oracleLOBDialect, column("PHOTO", "BLOB", 2000, domain.LOBTruncate),
+			"DBMS_LOB.SUBSTR(PHOTO, 2000, 1) AS PHOTO", false},
+		{"oracle clob over sql limit", oracleLOBDialect, column("NOTES", "CLOB", 1001, domain.LOBTruncate),
+			"CASE WHEN DBMS_LOB.GETLENGTH(NOTES) > 1001 THEN NULL ELSE NOTES END AS NOTES", true},
+		{"oracle blob over sql limit", oracleLOBDialect, column("PHOTO", "BLOB", 1<<20, domain.LOBTruncate),
+			"CASE WHEN DBMS_LOB.GETLENGTH(PHOTO) > 1048576 THEN NULL ELSE PHOTO END AS PHOTO", true},
+		{"oracle skip row", oracleLOBDialect, column("NOTES", "CLOB", 100, domain.LOBSkipRow),
+			"CASE WHEN DBMS_LOB.GETLENGTH(NOTES) > 100 THEN NULL ELSE NOTES END AS NOTES", false},
+		{"mariadb truncate", mariaDBLOBDialect, column("notes", "LONGTEXT", 1<<20, domain.LOBTruncate),
+			"LEFT(notes, 1048576) AS notes", false},
+		{"mariadb fail", mariaDBLOBDialect, column("notes", "LONGTEXT", 100, domain.LOBFail),
+			"CASE WHEN CHAR_LENGTH(notes) > 100 THEN NULL ELSE notes END AS notes", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := &domain.TableSchema{Columns: []domain.ColumnInfo{{Name: "ID"}, tt.column}}
+			exprs, names, lobs := buildLOBSelect(schema, tt.dialect)
+			if len(exprs) != 3 || exprs[0] != "ID" || exprs[1] != tt.wantValue {
+				t.Errorf("exprs = %q, want value %q", exprs, tt.wantValue)
+			}
+			if wantNames := []string{"ID", tt.column.Name, "LOBLEN_1"}; !reflect.DeepEqual(names, wantNames) {
+				t.Errorf("names = %q, want %q", names, wantNames)
+			}
+			if len(lobs) != 1 || lobs[0].chunked != tt.wantChunked {
+				t.Errorf("lobs = %+v, want chunked %t", lobs, tt.wantChunked)
+			}
+		})
+	}
+}
+
+func TestApplyLOBLimits(t *testing.T) {
+	limit := func(onExceed string, chunked bool) lobColumn {
+		return lobColumn{name: "NOTES", lenAlias: "LOBLEN_1", limit: domain.LOBLimit{MaxSize: 4, OnExceed: onExceed}, chunked: chunked}
+	}
+	tests := []struct {
+		name        string
+		lob         lobColumn
+		value       interface{}
+		length      int64
+		want        interface{}
+		wantChunked bool
+		wantErr     error
+	}{
+		{"within limit", limit(domain.LOBFail, false), "abc", 3, "abc", false, nil},
+		{"truncate", limit(domain.LOBTruncate, false), "abcdef", 6, "abcd", false, nil},
+		{"truncate binary", limit(domain.LOBTruncate, false), []byte{1, 2, 3, 4, 5}, 5, []byte{1, 2, 3, 4}, false, nil},
+		{"chunked over limit", limit(domain.LOBTruncate, true), nil, 6, nil, true, nil},
+		{"chunked within limit", limit(domain.LOBTruncate, true), "abc", 3, "abc", false, nil},
+		{"skip row", limit(domain.LOBSkipRow, false), nil, 6, domain.OversizedValue{Column: "NOTES", Size: 6}, false, nil},
+		{"fail", limit(domain.LOBFail, false), nil, 6, nil, false, domain.ErrLOBTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := domain.Record{"ID": int64(1), "NOTES": tt.value, "LOBLEN_1": tt.length}
+			chunked, err := applyLOBLimits(record, []lobColumn{tt.lob})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("applyLOBLimits = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if _, ok := record["LOBLEN_1"]; ok {
+				t.Error("length column left in the record")
+			}
+			if !reflect.DeepEqual(record["NOTES"], tt.want) {
+				t.Errorf("NOTES = %#v, want %#v", record["NOTES"], tt.want)
+			}
+			if (len(chunked) == 1) != tt.wantChunked {
+				t.Errorf("chunked = %+v, want %t", chunked, tt.wantChunked)
+			}
+		})
+	}
+}
+
+func TestOracleLOBChunkQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType string
+		max      int64
+		want     [][]string // Части каждого запроса: "размер, смещение"
+	}{
+		{"clob", "CLOB", 2500, [][]string{{"1000, 1", "1000, 1001", "500, 2001"}}},
+		{"nclob", "NCLOB", 1000, [][]string{{"1000, 1"}}},
+		{"blob over one query", "BLOB", 70000, [][]string{
+			chunkRange(2000, 1, 32),
+			{"2000, 64001", "2000, 66001", "2000, 68001"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := lobColumn{name: "DOC", dataType: tt.dataType, limit: domain.LOBLimit{MaxSize: tt.max, OnExceed: domain.LOBTruncate}}
+			offset := int64(1)
+			for i, chunks := range tt.want {
+				query, next := oracleLOBChunkQuery("APP.SIMS", lc, offset)
+				var exprs []string
+				for _, c := range chunks {
+					exprs = append(exprs, "DBMS_LOB.SUBSTR(DOC, "+c+")")
+				}
+				want := "SELECT " + strings.Join(exprs, ", ") + " FROM APP.SIMS WHERE ROWID = CHARTOROWID(:1)"
+				if query != want {
+					t.Fatalf("query %d = %s, want %s", i, query, want)
+				}
+				offset = next
+			}
+			if offset != tt.max+1 {
+				t.Errorf("reading stopped at offset %d, want %d", offset, tt.max+1)
+			}
+		})
+	}
+}
+
+// chunkRange возвращает n частей размера size подряд, начиная со смещения from
+func chunkRange(size, from int64, n int) []string {
+	var chunks []string
+	for i := 0; i < n; i++ {
+		chunks = append(chunks, fmt.Sprintf("%d, %d", size, from+int64(i)*size))
+	}
+	return chunks
+}
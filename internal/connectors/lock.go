@@ -0,0 +1,133 @@
+package connectors
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLockBusy - блокировка удерживается другой сессией
+var ErrLockBusy = errors.New("lock is held by another session")
+
+// Lock - именованная блокировка, удерживаемая отдельной сессией БД
+// (GET_LOCK в MariaDB, DBMS_LOCK в Oracle). Освобождается при Release
+// или при обрыве сессии
+type Lock struct {
+	name    string
+	conn    *sql.Conn
+	release func(ctx context.Context, conn *sql.Conn) error
+}
+
+// Name возвращает имя блокировки в БД
+func (l *Lock) Name() string {
+	return l.name
+}
+
+// Release освобождает блокировку и закрывает сессию. Если освободить не удалось,
+// сессия закрывается, а не возвращается в пул: иначе блокировка осталась бы
+// за простаивающим соединением до его закрытия
+func (l *Lock) Release() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := l.release(ctx, l.conn)
+	if err != nil {
+		// driver.ErrBadConn из Raw заставляет database/sql закрыть соединение
+		l.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		return fmt.Errorf("release lock %s: %w", l.name, err)
+	}
+	if err := l.conn.Close(); err != nil {
+		return fmt.Errorf("release lock %s: %w", l.name, err)
+	}
+	return nil
+}
+
+// lockName приводит имя блокировки к допустимой длине (MariaDB - 64 символа)
+func lockName(name string) string {
+	name = "db_swapper." + name
+	if len(name) <= 64 {
+		return name
+	}
+	sum := sha1.Sum([]byte(name))
+	return "db_swapper." + hex.EncodeToString(sum[:])
+}
+
+// AcquireLock захватывает блокировку name, ожидая не дольше timeout
+func (m *MariaDBConnector) AcquireLock(name string, timeout time.Duration) (*Lock, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+10*time.Second)
+	defer cancel()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("lock session failed: %w", err)
+	}
+
+	name = lockName(name)
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, int(timeout.Seconds())).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("GET_LOCK %s failed: %w", name, err)
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		conn.Close()
+		return nil, fmt.Errorf("%s: %w", name, ErrLockBusy)
+	}
+
+	return &Lock{name: name, conn: conn, release: func(ctx context.Context, conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, "DO RELEASE_LOCK(?)", name)
+		return err
+	}}, nil
+}
+
+// AcquireLock захватывает блокировку name через DBMS_LOCK, ожидая не дольше timeout.
+// Требуется право EXECUTE на DBMS_LOCK
+func (o *OracleConnector) AcquireLock(name string, timeout time.Duration) (*Lock, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+10*time.Second)
+	defer cancel()
+	conn, err := o.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("lock session failed: %w", err)
+	}
+
+	name = lockName(name)
+	// ALLOCATE_UNIQUE выполняет COMMIT, поэтому блокировка берется в отдельной сессии;
+	// release_on_commit => FALSE удерживает ее до явного RELEASE
+	var status int64
+	var handle string
+	_, err = conn.ExecContext(ctx, `
+		DECLARE
+			h VARCHAR2(128);
+		BEGIN
+			DBMS_LOCK.ALLOCATE_UNIQUE(:1, h);
+			:2 := DBMS_LOCK.REQUEST(lockhandle => h, lockmode => DBMS_LOCK.X_MODE,
+				timeout => :3, release_on_commit => FALSE);
+			:4 := h;
+		END;`,
+		name, sql.Out{Dest: &status}, int(timeout.Seconds()), sql.Out{Dest: &handle})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("DBMS_LOCK.REQUEST %s failed: %w", name, err)
+	}
+	// 0 - захвачена, 4 - уже удерживается этой сессией, 1 - таймаут
+	switch status {
+	case 0, 4:
+	case 1:
+		conn.Close()
+		return nil, fmt.Errorf("%s: %w", name, ErrLockBusy)
+	default:
+		conn.Close()
+		return nil, fmt.Errorf("DBMS_LOCK.REQUEST %s returned %d", name, status)
+	}
+
+	return &Lock{name: name, conn: conn, release: func(ctx context.Context, conn *sql.Conn) error {
+		var result int64
+		_, err := conn.ExecContext(ctx, "BEGIN :1 := DBMS_LOCK.RELEASE(:2); END;", sql.Out{Dest: &result}, handle)
+		if err == nil && result != 0 && result != 4 {
+			err = fmt.Errorf("DBMS_LOCK.RELEASE returned %d", result)
+		}
+		return err
+	}}, nil
+}
@@ -0,0 +1,102 @@
+package connectors
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// lockTestDriver - драйвер без БД: соединения только открываются и закрываются
+type lockTestDriver struct {
+	closed atomic.Int32
+}
+
+func (d *lockTestDriver) Open(string) (driver.Conn, error) {
+	return &lockTestConn{driver: d}, nil
+}
+
+type lockTestConn struct {
+	driver *lockTestDriver
+}
+
+func (c *lockTestConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *lockTestConn) Close() error {
+	c.driver.closed.Add(1)
+	return nil
+}
+
+func (c *lockTestConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+var lockDriver = &lockTestDriver{}
+
+func init() {
+	sql.Register("db_swapper_lock_test", lockDriver)
+}
+
+func TestLockRelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		releaseErr error
+		wantOpen   int // Соединений в пуле после Release
+	}{
+		{"released", nil, 1},
+		// Сессия с неснятой блокировкой не должна вернуться в пул
+		{"release failed", errors.New("ORA-03113: end-of-file on communication channel"), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sql.Open("db_swapper_lock_test", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			conn, err := db.Conn(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			closedBefore := lockDriver.closed.Load()
+
+			released := false
+			lock := &Lock{name: lockName("sims"), conn: conn, release: func(context.Context, *sql.Conn) error {
+				released = true
+				return tt.releaseErr
+			}}
+			err = lock.Release()
+			if !released {
+				t.Fatal("release function was not called")
+			}
+			if !errors.Is(err, tt.releaseErr) {
+				t.Errorf("Release() = %v, want %v", err, tt.releaseErr)
+			}
+			if open := db.Stats().OpenConnections; open != tt.wantOpen {
+				t.Errorf("open connections = %d, want %d", open, tt.wantOpen)
+			}
+			if closed := int(lockDriver.closed.Load() - closedBefore); closed != 1-tt.wantOpen {
+				t.Errorf("closed sessions = %d, want %d", closed, 1-tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestLockName(t *testing.T) {
+	if got := lockName("sims"); got != "db_swapper.sims" {
+		t.Errorf("lockName(sims) = %q", got)
+	}
+	long := strings.Repeat("subscriber_history_", 4)
+	got := lockName(long)
+	if len(got) > 64 || !strings.HasPrefix(got, "db_swapper.") {
+		t.Errorf("lockName(%q) = %q, want at most 64 characters", long, got)
+	}
+	if lockName(long+"x") == got {
+		t.Error("different long names share a lock")
+	}
+}
@@ -12,17 +12,29 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 )
 
+// Размер пула простаивающих соединений (восстанавливается после сброса пула)
+const mariaDBMaxIdleConns = 25
+
 type MariaDBConnector struct {
 	config config.DatabaseConfig
 	db     *sql.DB
+	loc    *time.Location // Часовой пояс, в котором БД хранит дату/время без пояса
+	retry  RetryPolicy    // Повтор читающих запросов
 }
 
 func NewMariaDBConnector(cfg config.DatabaseConfig) *MariaDBConnector {
-	return &MariaDBConnector{config: cfg}
+	return &MariaDBConnector{config: cfg, retry: NewRetryPolicy(cfg.Retry)}
 }
 
 func (m *MariaDBConnector) Connect() error {
-	connectionString := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
+	loc, err := domain.LoadLocation(m.config.TimeZone)
+	if err != nil {
+		return fmt.Errorf("invalid time_zone: %w", err)
+	}
+	m.loc = loc
+
+	// loc=UTC: драйвер не сдвигает DATETIME, перевод в пояс БД делают convertValue/toDBValue
+	connectionString := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&loc=UTC",
 		m.config.User, m.config.Password, m.config.Host, m.config.Port, m.config.DBName)
 	db, err := sql.Open("mysql", connectionString)
 	if err != nil {
@@ -30,7 +42,7 @@ func (m *MariaDBConnector) Connect() error {
 	}
 
 	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(25)
+	db.SetMaxIdleConns(mariaDBMaxIdleConns)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
 	if err := db.Ping(); err != nil {
@@ -41,6 +53,18 @@ func (m *MariaDBConnector) Connect() error {
 	return nil
 }
 
+// Location возвращает часовой пояс, в котором БД хранит дату/время
+func (m *MariaDBConnector) Location() *time.Location {
+	return m.location()
+}
+
+func (m *MariaDBConnector) location() *time.Location {
+	if m.loc == nil {
+		return time.Local
+	}
+	return m.loc
+}
+
 func (m *MariaDBConnector) Disconnect() error {
 	if m.db != nil {
 		return m.db.Close()
@@ -59,7 +83,17 @@ func (m *MariaDBConnector) Ping() error {
 	return m.db.PingContext(ctx)
 }
 
-func (m *MariaDBConnector) GetCount(schema *domain.TableSchema) (int, error) {
+// GetCount возвращает количество строк источника, повторяя запрос при временных ошибках
+func (m *MariaDBConnector) GetCount(ctx context.Context, schema *domain.TableSchema) (int, error) {
+	var count int
+	err := retryStatement(ctx, m.retry, m.db, mariaDBMaxIdleConns, func() (err error) {
+		count, err = m.getCount(ctx, schema)
+		return err
+	})
+	return count, err
+}
+
+func (m *MariaDBConnector) getCount(ctx context.Context, schema *domain.TableSchema) (int, error) {
 	if schema == nil {
 		return 0, fmt.Errorf("schema cannot be nil")
 	}
@@ -72,14 +106,24 @@ func (m *MariaDBConnector) GetCount(schema *domain.TableSchema) (int, error) {
 
 	query := fmt.Sprintf("SELECT COUNT(%s) FROM %s", column, schema.PrimaryKey)
 	var count int
-	err := m.db.QueryRow(query).Scan(&count)
+	err := m.db.QueryRowContext(ctx, query).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("count query failed: %w", err)
 	}
 	return count, nil
 }
 
-func (m *MariaDBConnector) GetBatch(tableName string, offset, batchSize int, schema *domain.TableSchema) ([]domain.Record, error) {
+// GetBatch читает пачку строк, повторяя запрос при временных ошибках
+func (m *MariaDBConnector) GetBatch(ctx context.Context, tableName string, offset, batchSize int, schema *domain.TableSchema) ([]domain.Record, error) {
+	var records []domain.Record
+	err := retryStatement(ctx, m.retry, m.db, mariaDBMaxIdleConns, func() (err error) {
+		records, err = m.getBatch(ctx, tableName, offset, batchSize, schema)
+		return err
+	})
+	return records, err
+}
+
+func (m *MariaDBConnector) getBatch(ctx context.Context, tableName string, offset, batchSize int, schema *domain.TableSchema) ([]domain.Record, error) {
 	if batchSize <= 0 {
 		return nil, fmt.Errorf("batchSize must be positive")
 	}
@@ -87,25 +131,26 @@ func (m *MariaDBConnector) GetBatch(tableName string, offset, batchSize int, sch
 		return nil, fmt.Errorf("offset cannot be negative")
 	}
 
-	// Собираем список колонок
+	// Собираем список колонок (для LOB с лимитом размер читается отдельной колонкой)
 	var columns []string
+	var lobs []lobColumn
 	if len(schema.Columns) > 0 {
-		columns = make([]string, len(schema.Columns))
-		for i, col := range schema.Columns {
-			columns[i] = col.Name
-		}
+		columns, _, lobs = buildLOBSelect(schema, mariaDBLOBDialect)
 	}
 
-	// Собираем запрос
-	var query string
+	// Собираем запрос. Пачки читаются в стабильном порядке, иначе соседние
+	// OFFSET могут пересекаться
+	selectList := "*"
 	if len(columns) > 0 {
-		query = fmt.Sprintf("SELECT %s FROM %s LIMIT ? OFFSET ?",
-			strings.Join(columns, ","), tableName)
-	} else {
-		query = fmt.Sprintf("SELECT * FROM %s LIMIT ? OFFSET ?", tableName)
+		selectList = strings.Join(columns, ",")
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", selectList, tableName)
+	if orderBy := batchOrder(schema); orderBy != "" {
+		query += " ORDER BY " + orderBy
 	}
+	query += " LIMIT ? OFFSET ?"
 
-	rows, err := m.db.Query(query, batchSize, offset)
+	rows, err := m.db.QueryContext(ctx, query, batchSize, offset)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -115,6 +160,7 @@ func (m *MariaDBConnector) GetBatch(tableName string, offset, batchSize int, sch
 	if err != nil {
 		return nil, fmt.Errorf("get columns failed: %w", err)
 	}
+	colTypes := columnDBTypes(rows)
 
 	// Предварительно выделяем срез с емкостью для записей batchSize
 	records := make([]domain.Record, 0, batchSize)
@@ -133,20 +179,15 @@ func (m *MariaDBConnector) GetBatch(tableName string, offset, batchSize int, sch
 
 		record := make(domain.Record, len(colNames))
 		for i, col := range colNames {
-			switch v := values[i].(type) {
-			case nil:
-				record[col] = nil
-			case []byte:
-				record[col] = string(v)
-			case time.Time:
-				record[col] = v
-			case string:
-				record[col] = v
-			case int64, float64, bool:
-				record[col] = v
-			default:
-				record[col] = fmt.Sprintf("%v", v)
+			val, err := convertValue(values[i], dbTypeAt(colTypes, i), m.location())
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", col, err)
 			}
+			record[col] = val
+		}
+		// LEFT обрезает любой лимит в запросе, дочитывать частями нечего
+		if _, err := applyLOBLimits(record, lobs); err != nil {
+			return nil, err
 		}
 		records = append(records, record)
 	}
@@ -157,6 +198,17 @@ func (m *MariaDBConnector) GetBatch(tableName string, offset, batchSize int, sch
 
 	return records, nil
 }
+
+// mariaDBLOBDialect: LEFT обрезает TEXT по символам, BLOB - по байтам
+var mariaDBLOBDialect = lobDialect{
+	length: func(col string) string {
+		return fmt.Sprintf("CHAR_LENGTH(%s)", col)
+	},
+	truncate: func(col domain.ColumnInfo, max int64) string {
+		return fmt.Sprintf("LEFT(%s, %d)", col.Name, max)
+	},
+}
+
 func (m *MariaDBConnector) CreateTempTable(originalTable, tempTable string, schema *domain.TableSchema) error {
 	tx, err := m.db.Begin()
 	if err != nil {
@@ -207,14 +259,14 @@ func (m *MariaDBConnector) CreateTempTable(originalTable, tempTable string, sche
 		}
 	}
 
-	return tx.Commit()
+	return commit(tx)
 }
 
-func (m *MariaDBConnector) InsertBatch(tableName string, records []domain.Record, columns []string) error {
+func (m *MariaDBConnector) InsertBatch(ctx context.Context, tableName string, records []domain.Record, columns []string) error {
 	if len(records) == 0 {
 		return nil
 	}
-	tx, err := m.db.Begin()
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("transaction begin failed: %w", err)
 	}
@@ -227,17 +279,54 @@ func (m *MariaDBConnector) InsertBatch(tableName string, records []domain.Record
 		}
 	}
 
+	// Строки с большими значениями пишем по одной через подготовленный запрос:
+	// драйвер передает такие параметры частями (COM_STMT_SEND_LONG_DATA),
+	// не собирая многострочный INSERT больше max_allowed_packet
+	var regular []domain.Record
+	var large []domain.Record
+	for _, record := range records {
+		if m.hasLargeValue(record, columns) {
+			large = append(large, record)
+		} else {
+			regular = append(regular, record)
+		}
+	}
+
+	if len(large) > 0 {
+		single, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+			tableName, strings.Join(columns, ","), strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",")))
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("prepare statement failed: %w", err)
+		}
+		defer single.Close()
+
+		for _, record := range large {
+			args := make([]interface{}, len(columns))
+			for i, col := range columns {
+				args[i] = toDBValue(record[col], m.location())
+			}
+			if _, err := single.ExecContext(ctx, args...); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("insert failed: %w", err)
+			}
+		}
+	}
+	if len(regular) == 0 {
+		return commit(tx)
+	}
+
 	stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", tableName, strings.Join(columns, ","))
 	var valueStrings []string
 	var valueArgs []interface{}
 
-	for _, record := range records {
+	for _, record := range regular {
 		var placeholders []string
 		for _, col := range columns {
 			placeholders = append(placeholders, "?")
 
 			if val, exists := record[col]; exists {
-				valueArgs = append(valueArgs, val)
+				valueArgs = append(valueArgs, toDBValue(val, m.location()))
 			} else {
 				valueArgs = append(valueArgs, nil)
 			}
@@ -245,39 +334,76 @@ func (m *MariaDBConnector) InsertBatch(tableName string, records []domain.Record
 		valueStrings = append(valueStrings, "("+strings.Join(placeholders, ",")+")")
 	}
 	stmt += strings.Join(valueStrings, ",")
-	if _, err := tx.Exec(stmt, valueArgs...); err != nil {
+	if _, err := tx.ExecContext(ctx, stmt, valueArgs...); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("insert failed: %w", err)
 	}
 
-	return tx.Commit()
+	return commit(tx)
 }
 
-func (m *MariaDBConnector) SwapTables(originalTable, tempTable string) error {
-	backupTable := originalTable + "_backup"
-	// удаляем старый бекап
-	m.DropTable(backupTable)
+// hasLargeValue сообщает, что запись содержит значение для записи частями
+func (m *MariaDBConnector) hasLargeValue(record domain.Record, columns []string) bool {
+	chunkSize := m.config.LOBChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultLOBChunkSize
+	}
+	for _, col := range columns {
+		if isLargeValue(record[col], chunkSize) {
+			return true
+		}
+	}
+	return false
+}
 
-	tx, err := m.db.Begin()
+// SwapTables атомарно публикует tempTable под именем originalTable.
+// Прежняя таблица сохраняется как резервная копия с отметкой времени
+func (m *MariaDBConnector) SwapTables(originalTable, tempTable string) error {
+	backupTable, err := NewBackupName(m, originalTable)
 	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("transaction begin failed: %w", err)
-	}
-	if _, err := tx.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", backupTable)); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("drop backup table failed: %w", err)
+		return err
 	}
 
+	// RENAME TABLE с несколькими парами выполняется атомарно
 	swapQuery := fmt.Sprintf("RENAME TABLE %s TO %s, %s TO %s",
 		originalTable, backupTable,
 		tempTable, originalTable)
 
-	if _, err := tx.Exec(swapQuery); err != nil {
-		tx.Rollback()
+	if _, err := m.db.Exec(swapQuery); err != nil {
 		return fmt.Errorf("swap tables failed: %w", err)
 	}
+	return nil
+}
+
+func (m *MariaDBConnector) ListTables(prefix string) ([]string, error) {
+	rows, err := m.db.Query(
+		"SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name LIKE ?",
+		likePrefix(prefix, '\\'))
+	if err != nil {
+		return nil, fmt.Errorf("list tables failed: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan table name failed: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
 
-	return tx.Commit()
+func (m *MariaDBConnector) CreateTableIfNotExists(tableName string, schema *domain.TableSchema) error {
+	if schema == nil || len(schema.Columns) == 0 {
+		return fmt.Errorf("schema cannot be empty")
+	}
+	createStmt := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", tableName, strings.Join(columnDefinitions(schema, true), ","))
+	if _, err := m.db.Exec(createStmt); err != nil {
+		return fmt.Errorf("create table failed: %w", err)
+	}
+	return nil
 }
 
 func (m *MariaDBConnector) DropTable(tableName string) error {
@@ -399,9 +525,20 @@ func (m *MariaDBConnector) ExecuteSelectWithSchema(query string, args ...interfa
 	}
 	return schema, nil
 }
-func (m *MariaDBConnector) ExecuteSelect(query string, args ...interface{}) ([]domain.Record, error) {
+
+// ExecuteSelect выполняет запрос, повторяя его при временных ошибках
+func (m *MariaDBConnector) ExecuteSelect(ctx context.Context, query string, args ...interface{}) ([]domain.Record, error) {
+	var records []domain.Record
+	err := retryStatement(ctx, m.retry, m.db, mariaDBMaxIdleConns, func() (err error) {
+		records, err = m.executeSelect(ctx, query, args...)
+		return err
+	})
+	return records, err
+}
+
+func (m *MariaDBConnector) executeSelect(ctx context.Context, query string, args ...interface{}) ([]domain.Record, error) {
 	// Выполняем запрос
-	rows, err := m.db.Query(query, args...)
+	rows, err := m.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query execution failed: %w", err)
 	}
@@ -412,6 +549,7 @@ func (m *MariaDBConnector) ExecuteSelect(query string, args ...interface{}) ([]d
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
+	colTypes := columnDBTypes(rows)
 
 	// Подготавливаем структуру для результатов
 	var records []domain.Record
@@ -433,27 +571,12 @@ func (m *MariaDBConnector) ExecuteSelect(query string, args ...interface{}) ([]d
 		// Создаем запись
 		record := make(domain.Record)
 		for i, colName := range columns {
-			// Обрабатываем NULL значения и специальные типы
-			if values[i] == nil {
-				record[colName] = nil
-				continue
-			}
-
-			// Конвертируем []byte в string (для TEXT, BLOB и т.д.)
-			switch v := values[i].(type) {
-			case []byte:
-				record[colName] = string(v)
-			case time.Time:
-				record[colName] = v
-			case int64:
-				record[colName] = v
-			case float64:
-				record[colName] = v
-			case bool:
-				record[colName] = v
-			default:
-				record[colName] = fmt.Sprintf("%v", v)
+			// Обрабатываем NULL значения и специальные типы (DECIMAL без потери точности)
+			val, err := convertValue(values[i], dbTypeAt(colTypes, i), m.location())
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", colName, err)
 			}
+			record[colName] = val
 		}
 
 		records = append(records, record)
@@ -466,3 +589,380 @@ func (m *MariaDBConnector) ExecuteSelect(query string, args ...interface{}) ([]d
 
 	return records, nil
 }
+
+// PublishedTable возвращает таблицу, на которую указывает представление name
+func (m *MariaDBConnector) PublishedTable(name string) (string, error) {
+	rows, err := m.db.Query(
+		"SELECT view_definition FROM information_schema.views WHERE table_schema = DATABASE() AND table_name = ?", name)
+	if err != nil {
+		return "", fmt.Errorf("query view failed: %w", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return "", rows.Err()
+	}
+	var definition string
+	if err := rows.Scan(&definition); err != nil {
+		return "", fmt.Errorf("scan view definition failed: %w", err)
+	}
+	return viewSourceTable(definition), nil
+}
+
+// viewSourceTable извлекает имя таблицы из определения вида
+// "select `db`.`t_a`.`id` AS `id` from `db`.`t_a`"
+func viewSourceTable(definition string) string {
+	i := strings.LastIndex(strings.ToLower(definition), " from ")
+	if i < 0 {
+		return ""
+	}
+	source := strings.Fields(definition[i+len(" from "):])
+	if len(source) == 0 {
+		return ""
+	}
+	name := strings.ReplaceAll(source[0], "`", "")
+	if j := strings.LastIndexByte(name, '.'); j >= 0 {
+		name = name[j+1:]
+	}
+	return name
+}
+
+// PublishTable переключает представление name на table одним CREATE OR REPLACE VIEW.
+// Права, выданные на name, в MariaDB хранятся по имени и продолжают действовать
+func (m *MariaDBConnector) PublishTable(name, table string) error {
+	var baseTables int
+	if err := m.db.QueryRow(
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ? AND table_type = 'BASE TABLE'",
+		name).Scan(&baseTables); err != nil {
+		return fmt.Errorf("check table type failed: %w", err)
+	}
+	if baseTables == 0 {
+		if _, err := m.db.Exec(fmt.Sprintf("CREATE OR REPLACE VIEW %s AS SELECT * FROM %s", name, table)); err != nil {
+			return fmt.Errorf("publish view failed: %w", err)
+		}
+		return nil
+	}
+
+	// Переход с замены таблиц: представление создается под временным именем и занимает
+	// место прежней таблицы тем же RENAME TABLE, что уносит ее в резервную копию.
+	// При любой ошибке таблица name остается на месте
+	tmpView := name + "_publish"
+	if _, err := m.db.Exec(fmt.Sprintf("CREATE OR REPLACE VIEW %s AS SELECT * FROM %s", tmpView, table)); err != nil {
+		return fmt.Errorf("publish view failed: %w", err)
+	}
+	backupTable, err := NewBackupName(m, name)
+	if err != nil {
+		return err
+	}
+	if _, err := m.db.Exec(fmt.Sprintf("RENAME TABLE %s TO %s, %s TO %s", name, backupTable, tmpView, name)); err != nil {
+		if _, dropErr := m.db.Exec(fmt.Sprintf("DROP VIEW IF EXISTS %s", tmpView)); dropErr != nil {
+			return fmt.Errorf("publish view failed: %w (drop %s: %v)", err, tmpView, dropErr)
+		}
+		return fmt.Errorf("publish view failed: %w", err)
+	}
+	return nil
+}
+
+func (m *MariaDBConnector) CopyGrants(fromTable, toTable string) error {
+	rows, err := m.db.Query(
+		`SELECT grantee, privilege_type, is_grantable FROM information_schema.table_privileges
+		 WHERE table_schema = DATABASE() AND table_name = ?`, fromTable)
+	if err != nil {
+		return fmt.Errorf("query grants failed: %w", err)
+	}
+	var grants []string
+	for rows.Next() {
+		var grantee, privilege, grantable string
+		if err := rows.Scan(&grantee, &privilege, &grantable); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan grant failed: %w", err)
+		}
+		stmt := fmt.Sprintf("GRANT %s ON %s TO %s", privilege, toTable, grantee)
+		if grantable == "YES" {
+			stmt += " WITH GRANT OPTION"
+		}
+		grants = append(grants, stmt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query grants failed: %w", err)
+	}
+
+	for _, stmt := range grants {
+		if _, err := m.db.Exec(stmt); err != nil {
+			return fmt.Errorf("copy grant failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// BuildIndexes создает первичный ключ и индексы схемы после загрузки данных
+func (m *MariaDBConnector) BuildIndexes(ctx context.Context, tableName string, schema *domain.TableSchema, progress func(done, total int, index string)) error {
+	total := len(schema.Indexes)
+	if schema.PrimaryKey != "" {
+		total++
+	}
+	done := 0
+	if schema.PrimaryKey != "" {
+		if _, err := m.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s)", tableName, schema.PrimaryKey)); err != nil {
+			return fmt.Errorf("add primary key failed: %w", err)
+		}
+		done++
+		progress(done, total, "PRIMARY KEY ("+schema.PrimaryKey+")")
+	}
+	for _, index := range schema.Indexes {
+		if _, err := m.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD INDEX idx_%s (%s)", tableName, index, index)); err != nil {
+			return fmt.Errorf("create index %s failed: %w", index, err)
+		}
+		done++
+		progress(done, total, index)
+	}
+	return nil
+}
+
+// AnalyzeTable обновляет статистику таблицы для оптимизатора
+func (m *MariaDBConnector) AnalyzeTable(ctx context.Context, tableName string) error {
+	if _, err := m.db.ExecContext(ctx, fmt.Sprintf("ANALYZE TABLE %s", tableName)); err != nil {
+		return fmt.Errorf("analyze table failed: %w", err)
+	}
+	return nil
+}
+
+// CloneMetadata переносит с fromTable на toTable внешние ключи, CHECK-ограничения,
+// триггеры и комментарии. Права в MariaDB выдаются по имени таблицы и после
+// RENAME TABLE продолжают действовать, поэтому не копируются
+func (m *MariaDBConnector) CloneMetadata(ctx context.Context, fromTable, toTable string) (*MetadataReport, error) {
+	report := &MetadataReport{}
+	steps := []func(context.Context, string, string, *MetadataReport) error{
+		m.cloneForeignKeys,
+		m.cloneChecks,
+		m.cloneTriggers,
+		m.cloneComments,
+	}
+	for _, step := range steps {
+		if err := step(ctx, fromTable, toTable, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// mariaDBQuote экранирует строковый литерал с учетом обратной косой черты
+func mariaDBQuote(s string) string {
+	return quoteString(strings.ReplaceAll(s, `\`, `\\`))
+}
+
+func (m *MariaDBConnector) cloneForeignKeys(ctx context.Context, fromTable, toTable string, report *MetadataReport) error {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT k.constraint_name, k.column_name, k.referenced_table_name, k.referenced_column_name,
+		       r.update_rule, r.delete_rule
+		  FROM information_schema.key_column_usage k
+		  JOIN information_schema.referential_constraints r
+		    ON r.constraint_schema = k.constraint_schema AND r.constraint_name = k.constraint_name
+		 WHERE k.table_schema = DATABASE() AND k.table_name = ?
+		 ORDER BY k.constraint_name, k.ordinal_position`, fromTable)
+	if err != nil {
+		return fmt.Errorf("query foreign keys failed: %w", err)
+	}
+	type foreignKey struct {
+		name, refTable, onUpdate, onDelete string
+		columns, refColumns                []string
+	}
+	var keys []*foreignKey
+	for rows.Next() {
+		var name, column, refTable, refColumn, onUpdate, onDelete string
+		if err := rows.Scan(&name, &column, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan foreign key failed: %w", err)
+		}
+		if n := len(keys); n == 0 || keys[n-1].name != name {
+			keys = append(keys, &foreignKey{name: name, refTable: refTable, onUpdate: onUpdate, onDelete: onDelete})
+		}
+		fk := keys[len(keys)-1]
+		fk.columns = append(fk.columns, column)
+		fk.refColumns = append(fk.refColumns, refColumn)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query foreign keys failed: %w", err)
+	}
+
+	for _, fk := range keys {
+		// Имя не указываем: InnoDB сгенерирует <таблица>_ibfk_N и переименует его вместе с таблицей
+		desc := fmt.Sprintf("(%s) -> %s(%s)", strings.Join(fk.columns, ","), fk.refTable, strings.Join(fk.refColumns, ","))
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD FOREIGN KEY (%s) REFERENCES %s (%s) ON UPDATE %s ON DELETE %s",
+			toTable, strings.Join(fk.columns, ", "), fk.refTable, strings.Join(fk.refColumns, ", "), fk.onUpdate, fk.onDelete)
+		if _, err := m.db.ExecContext(ctx, stmt); err != nil {
+			report.skipped("foreign key", desc, err)
+			continue
+		}
+		report.copied("foreign key", desc)
+	}
+
+	// Внешние ключи других таблиц продолжают ссылаться на прежнюю таблицу
+	var children []string
+	childRows, err := m.db.QueryContext(ctx, `
+		SELECT DISTINCT table_name, constraint_name FROM information_schema.key_column_usage
+		 WHERE table_schema = DATABASE() AND referenced_table_name = ?`, fromTable)
+	if err != nil {
+		return fmt.Errorf("query referencing foreign keys failed: %w", err)
+	}
+	for childRows.Next() {
+		var table, name string
+		if err := childRows.Scan(&table, &name); err != nil {
+			childRows.Close()
+			return fmt.Errorf("scan referencing foreign key failed: %w", err)
+		}
+		children = append(children, table+"."+name)
+	}
+	childRows.Close()
+	for _, c := range children {
+		report.skipped("referencing foreign key", c, "other tables keep referencing the previous table")
+	}
+	return childRows.Err()
+}
+
+func (m *MariaDBConnector) cloneChecks(ctx context.Context, fromTable, toTable string, report *MetadataReport) error {
+	existing := make(map[string]bool)
+	checks := make(map[string]string)
+	var names []string
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT table_name, constraint_name, check_clause FROM information_schema.check_constraints
+		 WHERE constraint_schema = DATABASE() AND table_name IN (?, ?)`, fromTable, toTable)
+	if err != nil {
+		return fmt.Errorf("query check constraints failed: %w", err)
+	}
+	for rows.Next() {
+		var table, name, clause string
+		if err := rows.Scan(&table, &name, &clause); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan check constraint failed: %w", err)
+		}
+		if strings.EqualFold(table, toTable) {
+			existing[strings.ToLower(name)] = true
+			continue
+		}
+		checks[name] = clause
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query check constraints failed: %w", err)
+	}
+
+	for _, name := range names {
+		if existing[strings.ToLower(name)] {
+			continue
+		}
+		// Имена CHECK-ограничений в MariaDB уникальны в пределах таблицы
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT `%s` CHECK (%s)", toTable, name, checks[name])
+		if _, err := m.db.ExecContext(ctx, stmt); err != nil {
+			report.skipped("check", name, err)
+			continue
+		}
+		report.copied("check", name)
+	}
+	return nil
+}
+
+func (m *MariaDBConnector) cloneTriggers(ctx context.Context, fromTable, toTable string, report *MetadataReport) error {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT trigger_name, action_timing, event_manipulation, action_statement
+		  FROM information_schema.triggers
+		 WHERE event_object_schema = DATABASE() AND event_object_table = ?
+		 ORDER BY action_order`, fromTable)
+	if err != nil {
+		return fmt.Errorf("query triggers failed: %w", err)
+	}
+	type trigger struct{ name, timing, event, body string }
+	var triggers []trigger
+	for rows.Next() {
+		var t trigger
+		if err := rows.Scan(&t.name, &t.timing, &t.event, &t.body); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan trigger failed: %w", err)
+		}
+		triggers = append(triggers, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query triggers failed: %w", err)
+	}
+
+	for _, t := range triggers {
+		// Имена триггеров уникальны в схеме, прежний триггер остается на резервной копии
+		name := versionedName(t.name)
+		stmt := fmt.Sprintf("CREATE TRIGGER `%s` %s %s ON %s FOR EACH ROW %s", name, t.timing, t.event, toTable, t.body)
+		if _, err := m.db.ExecContext(ctx, stmt); err != nil {
+			report.skipped("trigger", t.name, err)
+			continue
+		}
+		report.copied("trigger", t.name+" as "+name)
+	}
+	return nil
+}
+
+func (m *MariaDBConnector) cloneComments(ctx context.Context, fromTable, toTable string, report *MetadataReport) error {
+	var comment string
+	err := m.db.QueryRowContext(ctx,
+		"SELECT table_comment FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?",
+		fromTable).Scan(&comment)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("query table comment failed: %w", err)
+	}
+	if comment != "" {
+		if _, err := m.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s COMMENT = %s", toTable, mariaDBQuote(comment))); err != nil {
+			report.skipped("comment", toTable, err)
+		} else {
+			report.copied("comment", toTable)
+		}
+	}
+
+	// Комментарий колонки задается вместе с ее определением, поэтому переносим его
+	// только на простые колонки новой таблицы (без значения по умолчанию и EXTRA)
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT f.column_name, f.column_comment, t.column_type, t.is_nullable,
+		       t.column_default IS NULL AND t.extra = '' AS simple
+		  FROM information_schema.columns f
+		  JOIN information_schema.columns t
+		    ON t.table_schema = f.table_schema AND t.table_name = ? AND t.column_name = f.column_name
+		 WHERE f.table_schema = DATABASE() AND f.table_name = ?
+		   AND f.column_comment <> '' AND t.column_comment = ''`, toTable, fromTable)
+	if err != nil {
+		return fmt.Errorf("query column comments failed: %w", err)
+	}
+	type columnComment struct {
+		name, comment, columnType, nullable string
+		simple                              bool
+	}
+	var comments []columnComment
+	for rows.Next() {
+		var c columnComment
+		if err := rows.Scan(&c.name, &c.comment, &c.columnType, &c.nullable, &c.simple); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan column comment failed: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query column comments failed: %w", err)
+	}
+
+	for _, c := range comments {
+		if !c.simple {
+			report.skipped("column comment", c.name, "column has default or extra attributes")
+			continue
+		}
+		nullable := "NULL"
+		if c.nullable == "NO" {
+			nullable = "NOT NULL"
+		}
+		stmt := fmt.Sprintf("ALTER TABLE %s MODIFY `%s` %s %s COMMENT %s", toTable, c.name, c.columnType, nullable, mariaDBQuote(c.comment))
+		if _, err := m.db.ExecContext(ctx, stmt); err != nil {
+			report.skipped("column comment", c.name, err)
+			continue
+		}
+		report.copied("column comment", c.name)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package connectors
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetadataReport - результат переноса метаданных таблицы
+type MetadataReport struct {
+	Copied  []string // Перенесенные объекты: "foreign key (A) -> T(B)"
+	Skipped []string // Пропущенные объекты с причиной
+}
+
+func (r *MetadataReport) copied(kind, name string) {
+	r.Copied = append(r.Copied, fmt.Sprintf("%s %s", kind, name))
+}
+
+func (r *MetadataReport) skipped(kind, name string, reason interface{}) {
+	r.Skipped = append(r.Skipped, fmt.Sprintf("%s %s: %v", kind, name, reason))
+}
+
+var versionSuffix = regexp.MustCompile(`__[0-9a-z]+$`)
+
+// versionedName возвращает новое имя объекта, уникального в схеме (триггера).
+// Объект остается на резервной копии таблицы под прежним именем, поэтому
+// к базовому имени добавляется суффикс версии
+func versionedName(name string) string {
+	base := versionSuffix.ReplaceAllString(strings.ToLower(name), "")
+	return base + "__" + strconv.FormatInt(time.Now().Unix(), 36)
+}
+
+// quoteString экранирует строковый литерал SQL
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
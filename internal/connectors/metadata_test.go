@@ -0,0 +1,114 @@
+package connectors
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// versionedNames заменяет суффиксы версий триггеров на "__V"
+var versionedNames = regexp.MustCompile(`(?i)__[0-9a-z]+\b`)
+
+func TestOracleCloneMetadata(t *testing.T) {
+	script := &scriptDB{}
+	script.on("c.constraint_type = 'R' AND c.status",
+		[]string{"constraint_name", "column_name", "table_name", "column_name", "delete_rule"},
+		[]driver.Value{"FK_SIMS_PLAN", "PLAN_ID", "PLANS", "ID", "SET NULL"},
+		[]driver.Value{"FK_SIMS_PLAN", "PLAN_VER", "PLANS", "VER", "SET NULL"},
+		[]driver.Value{"FK_SIMS_REGION", "REGION_ID", "REGIONS", "ID", "NO ACTION"},
+		[]driver.Value{"FK_SIMS_TARIFF", "TARIFF_ID", "TARIFFS", "ID", "CASCADE"})
+	script.on("p.table_name = :1", []string{"table_name", "constraint_name"},
+		[]driver.Value{"ORDERS", "FK_ORDERS_SIMS"})
+	script.on("constraint_type = 'C'", []string{"constraint_name", "generated", "search_condition"},
+		[]driver.Value{"SYS_C0012", "GENERATED NAME", `"MSISDN" IS NOT NULL`},
+		[]driver.Value{"CK_SIMS_STATUS", "USER NAME", "STATUS IN ('A', 'B')"})
+	script.on("FROM user_triggers", []string{"trigger_name", "description", "when_clause", "trigger_body"},
+		[]driver.Value{"TRG_SIMS_BI", "TRG_SIMS_BI\nBEFORE INSERT ON SIMS\nFOR EACH ROW\n", " ", "BEGIN :new.id := 1; END;"},
+		[]driver.Value{"TRG_SIMS_BU__SJ0T2O", "trg_sims_bu__sj0t2o\nBEFORE UPDATE OF STATUS ON \"APP\".\"SIMS\"\nFOR EACH ROW\n",
+			"new.status <> old.status", "BEGIN NULL; END;"})
+	script.on("user_tab_comments", []string{"comments"}, []driver.Value{"SIM's"})
+	script.fail("REFERENCES REGIONS", errors.New("ORA-02270: no matching unique or primary key"))
+	o := &OracleConnector{db: script.open(t)}
+
+	report, err := o.CloneMetadata(context.Background(), "SIMS", "SIMS_B")
+	if err != nil {
+		t.Fatalf("CloneMetadata: %v", err)
+	}
+
+	want := []string{
+		"ALTER TABLE SIMS_B ADD FOREIGN KEY (PLAN_ID, PLAN_VER) REFERENCES PLANS (ID, VER) ON DELETE SET NULL",
+		"ALTER TABLE SIMS_B ADD FOREIGN KEY (TARIFF_ID) REFERENCES TARIFFS (ID) ON DELETE CASCADE",
+		// Системное NOT NULL переносится определением колонки
+		"ALTER TABLE SIMS_B ADD CHECK (STATUS IN ('A', 'B'))",
+		"CREATE TRIGGER TRG_SIMS_BI__V BEFORE INSERT ON SIMS_B\nFOR EACH ROW\nBEGIN :new.id := 1; END;",
+		"CREATE TRIGGER TRG_SIMS_BU__V BEFORE UPDATE OF STATUS ON SIMS_B\nFOR EACH ROW WHEN (new.status <> old.status)\nBEGIN NULL; END;",
+		"COMMENT ON TABLE SIMS_B IS 'SIM''s'",
+	}
+	var got []string
+	for _, stmt := range script.executed() {
+		got = append(got, versionedNames.ReplaceAllString(stmt, "__V"))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("executed:\n%s\nwant:\n%s", strings.Join(got, "\n--\n"), strings.Join(want, "\n--\n"))
+	}
+
+	wantSkipped := []string{
+		"foreign key (REGION_ID) -> REGIONS(ID): ORA-02270: no matching unique or primary key",
+		"referencing foreign key ORDERS.FK_ORDERS_SIMS: other tables keep referencing the previous table",
+	}
+	if !reflect.DeepEqual(report.Skipped, wantSkipped) {
+		t.Errorf("skipped = %q, want %q", report.Skipped, wantSkipped)
+	}
+	if n := len(report.Copied); n != 7 {
+		t.Errorf("copied %d objects, want 7: %q", n, report.Copied)
+	}
+}
+
+func TestMariaDBCloneMetadata(t *testing.T) {
+	script := &scriptDB{}
+	script.on("referential_constraints",
+		[]string{"constraint_name", "column_name", "referenced_table_name", "referenced_column_name", "update_rule", "delete_rule"},
+		[]driver.Value{"sims_ibfk_1", "tariff_id", "tariffs", "id", "CASCADE", "RESTRICT"},
+		[]driver.Value{"sims_ibfk_2", "plan_id", "plans", "id", "NO ACTION", "SET NULL"},
+		[]driver.Value{"sims_ibfk_2", "plan_ver", "plans", "ver", "NO ACTION", "SET NULL"})
+	script.on("referenced_table_name = ?", []string{"table_name", "constraint_name"},
+		[]driver.Value{"orders", "orders_ibfk_1"})
+	script.on("check_constraints", []string{"table_name", "constraint_name", "check_clause"},
+		[]driver.Value{"sims", "ck_status", "`status` in ('A','B')"},
+		[]driver.Value{"sims", "ck_imsi", "octet_length(`imsi`) = 15"},
+		// Ограничение уже создано определением новой таблицы
+		[]driver.Value{"sims_b", "CK_IMSI", "octet_length(`imsi`) = 15"})
+	script.on("information_schema.triggers", []string{"trigger_name", "action_timing", "event_manipulation", "action_statement"},
+		[]driver.Value{"sims_bi", "BEFORE", "INSERT", "SET NEW.created = NOW()"},
+		[]driver.Value{"sims_bu__sj0t2o", "BEFORE", "UPDATE", "SET NEW.updated = NOW()"})
+	m := &MariaDBConnector{db: script.open(t)}
+
+	report, err := m.CloneMetadata(context.Background(), "sims", "sims_b")
+	if err != nil {
+		t.Fatalf("CloneMetadata: %v", err)
+	}
+
+	want := []string{
+		"ALTER TABLE sims_b ADD FOREIGN KEY (tariff_id) REFERENCES tariffs (id) ON UPDATE CASCADE ON DELETE RESTRICT",
+		"ALTER TABLE sims_b ADD FOREIGN KEY (plan_id, plan_ver) REFERENCES plans (id, ver) ON UPDATE NO ACTION ON DELETE SET NULL",
+		"ALTER TABLE sims_b ADD CONSTRAINT `ck_status` CHECK (`status` in ('A','B'))",
+		"CREATE TRIGGER `sims_bi__V` BEFORE INSERT ON sims_b FOR EACH ROW SET NEW.created = NOW()",
+		"CREATE TRIGGER `sims_bu__V` BEFORE UPDATE ON sims_b FOR EACH ROW SET NEW.updated = NOW()",
+	}
+	var got []string
+	for _, stmt := range script.executed() {
+		got = append(got, versionedNames.ReplaceAllString(stmt, "__V"))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("executed:\n%s\nwant:\n%s", strings.Join(got, "\n--\n"), strings.Join(want, "\n--\n"))
+	}
+
+	wantSkipped := []string{"referencing foreign key orders.orders_ibfk_1: other tables keep referencing the previous table"}
+	if !reflect.DeepEqual(report.Skipped, wantSkipped) {
+		t.Errorf("skipped = %q, want %q", report.Skipped, wantSkipped)
+	}
+}
@@ -6,22 +6,38 @@ import (
 	"db_swapper/internal/config"
 	"db_swapper/internal/domain"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
-	_ "github.com/sijms/go-ora/v2"
+	go_ora "github.com/sijms/go-ora/v2"
 )
 
+// oracleMaxInlineBind - максимальный размер строки, передаваемой как VARCHAR2/RAW
+const oracleMaxInlineBind = 32767
+
+// Размер пула простаивающих соединений (восстанавливается после сброса пула)
+const oracleMaxIdleConns = 5
+
 type OracleConnector struct {
 	config config.DatabaseConfig
 	db     *sql.DB
+	loc    *time.Location // Часовой пояс, в котором БД хранит дату/время без пояса
+	retry  RetryPolicy    // Повтор читающих запросов
 }
 
 func NewOracleConnector(cfg config.DatabaseConfig) *OracleConnector {
-	return &OracleConnector{config: cfg}
+	return &OracleConnector{config: cfg, retry: NewRetryPolicy(cfg.Retry)}
 }
 
 func (o *OracleConnector) Connect() error {
+	loc, err := domain.LoadLocation(o.config.TimeZone)
+	if err != nil {
+		return fmt.Errorf("invalid time_zone: %w", err)
+	}
+	o.loc = loc
+
 	connectionString := fmt.Sprintf(
 		"oracle://%s:%s@%s:%d/%s",
 		o.config.User,
@@ -37,7 +53,7 @@ func (o *OracleConnector) Connect() error {
 	}
 
 	db.SetConnMaxLifetime(5 * time.Minute)
-	db.SetMaxIdleConns(5)
+	db.SetMaxIdleConns(oracleMaxIdleConns)
 	db.SetMaxOpenConns(20)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -62,6 +78,18 @@ func (o *OracleConnector) Ping() error {
 	return o.db.PingContext(ctx)
 }
 
+// Location возвращает часовой пояс, в котором БД хранит DATE/TIMESTAMP
+func (o *OracleConnector) Location() *time.Location {
+	return o.location()
+}
+
+func (o *OracleConnector) location() *time.Location {
+	if o.loc == nil {
+		return time.Local
+	}
+	return o.loc
+}
+
 func (o *OracleConnector) Disconnect() error {
 	if o.db != nil {
 		return o.db.Close()
@@ -69,7 +97,17 @@ func (o *OracleConnector) Disconnect() error {
 	return nil
 }
 
-func (o *OracleConnector) GetCount(schema *domain.TableSchema) (int, error) {
+// GetCount возвращает количество строк источника, повторяя запрос при временных ошибках
+func (o *OracleConnector) GetCount(ctx context.Context, schema *domain.TableSchema) (int, error) {
+	var count int
+	err := retryStatement(ctx, o.retry, o.db, oracleMaxIdleConns, func() (err error) {
+		count, err = o.getCount(ctx, schema)
+		return err
+	})
+	return count, err
+}
+
+func (o *OracleConnector) getCount(ctx context.Context, schema *domain.TableSchema) (int, error) {
 	if schema == nil {
 		return 0, fmt.Errorf("schema cannot be nil")
 	}
@@ -83,14 +121,24 @@ func (o *OracleConnector) GetCount(schema *domain.TableSchema) (int, error) {
 	// Предположим, что имя таблицы хранится в поле PrimaryKey схемы.
 	query := fmt.Sprintf("SELECT COUNT(%s) FROM %s", column, schema.PrimaryKey)
 	var count int
-	err := o.db.QueryRow(query).Scan(&count)
+	err := o.db.QueryRowContext(ctx, query).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("count query failed: %w", err)
 	}
 	return count, nil
 }
 
-func (o *OracleConnector) GetBatch(tableName string, offset, batchSize int, schema *domain.TableSchema) ([]domain.Record, error) {
+// GetBatch читает пачку строк, повторяя запрос при временных ошибках
+func (o *OracleConnector) GetBatch(ctx context.Context, tableName string, offset, batchSize int, schema *domain.TableSchema) ([]domain.Record, error) {
+	var records []domain.Record
+	err := retryStatement(ctx, o.retry, o.db, oracleMaxIdleConns, func() (err error) {
+		records, err = o.getBatch(ctx, tableName, offset, batchSize, schema)
+		return err
+	})
+	return records, err
+}
+
+func (o *OracleConnector) getBatch(ctx context.Context, tableName string, offset, batchSize int, schema *domain.TableSchema) ([]domain.Record, error) {
 	if batchSize <= 0 {
 		return nil, fmt.Errorf("batchSize must be positive")
 	}
@@ -98,27 +146,47 @@ func (o *OracleConnector) GetBatch(tableName string, offset, batchSize int, sche
 		return nil, fmt.Errorf("offset cannot be negative")
 	}
 
-	// Собираем select
-	var selectClause string
+	// Собираем select (для LOB с лимитом размер читается отдельной колонкой)
+	var selectClause, innerClause string
+	var lobs []lobColumn
+	chunked := false
 	if len(schema.Columns) > 0 {
-		columns := make([]string, len(schema.Columns))
-		for i, col := range schema.Columns {
-			columns[i] = col.Name
+		var exprs, names []string
+		exprs, names, lobs = buildLOBSelect(schema, oracleLOBDialect)
+		for _, lc := range lobs {
+			chunked = chunked || lc.chunked
+		}
+		// Значения, дочитываемые частями, находятся по ROWID
+		if chunked {
+			exprs = append(exprs, "ROWIDTOCHAR(ROWID) AS "+oracleLOBRowID)
+			names = append(names, oracleLOBRowID)
 		}
-		selectClause = strings.Join(columns, ", ")
+		innerClause = strings.Join(exprs, ", ")
+		selectClause = strings.Join(names, ", ")
 	} else {
 		selectClause = "*"
+		innerClause = "*"
 	}
 
-	// Делаем пагинацию для оракла
+	// Делаем пагинацию для оракла. Нумерация строк идет в стабильном порядке,
+	// иначе пачки разных запросов могут пересекаться
+	orderBy := batchOrder(schema)
+	if orderBy == "" {
+		orderBy = "ROWID"
+	}
+	// Строки с NULL в ключе отбрасываются, только если ключ задан схемой
+	where := ""
+	if schema.PrimaryKey != "" {
+		where = schema.PrimaryKey + " IS NOT NULL AND "
+	}
 	query := fmt.Sprintf(
 		`SELECT %s FROM (
-            SELECT %s, ROW_NUMBER() OVER (ORDER BY 1) AS rn 
+            SELECT %s, ROW_NUMBER() OVER (ORDER BY %s) AS rn 
             FROM %s
-        ) WHERE %s IS NOT NULL AND (rn > %d AND rn <= %d)`,
-		selectClause, selectClause, tableName, schema.PrimaryKey, offset, offset+batchSize)
+        ) WHERE %s(rn > %d AND rn <= %d)`,
+		selectClause, innerClause, orderBy, tableName, where, offset, offset+batchSize)
 
-	rows, err := o.db.Query(query)
+	rows, err := o.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
@@ -128,9 +196,11 @@ func (o *OracleConnector) GetBatch(tableName string, offset, batchSize int, sche
 	if err != nil {
 		return nil, fmt.Errorf("get columns failed: %w", err)
 	}
+	colTypes := columnDBTypes(rows)
 
 	// Предварительно выделяем срез с емкостью для записей batchSize
 	records := make([]domain.Record, 0, batchSize)
+	var pending []oracleLOBRead
 	values := make([]interface{}, len(colNames))
 	valuePtrs := make([]interface{}, len(colNames))
 
@@ -146,19 +216,21 @@ func (o *OracleConnector) GetBatch(tableName string, offset, batchSize int, sche
 
 		record := make(domain.Record, len(colNames))
 		for i, col := range colNames {
-			switch v := values[i].(type) {
-			case nil:
-				record[col] = nil
-			case []byte:
-				record[col] = string(v)
-			case time.Time:
-				record[col] = v
-			case string:
-				record[col] = v
-			case int64, float64, bool:
-				record[col] = v
-			default:
-				record[col] = fmt.Sprintf("%v", v)
+			val, err := convertValue(values[i], dbTypeAt(colTypes, i), o.location())
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", col, err)
+			}
+			record[col] = val
+		}
+		oversized, err := applyLOBLimits(record, lobs)
+		if err != nil {
+			return nil, err
+		}
+		if chunked {
+			rowID, _ := findValue(record, oracleLOBRowID).(string)
+			deleteValue(record, oracleLOBRowID)
+			if len(oversized) > 0 {
+				pending = append(pending, oracleLOBRead{record: record, rowID: rowID, columns: oversized})
 			}
 		}
 		records = append(records, record)
@@ -167,27 +239,148 @@ func (o *OracleConnector) GetBatch(tableName string, offset, batchSize int, sche
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("rows error: %w", err)
 	}
+	// Пачка прочитана: соединение запроса освобождается до дочитывания LOB
+	rows.Close()
+	for _, p := range pending {
+		for _, lc := range p.columns {
+			val, err := o.readLOBPrefix(ctx, tableName, p.rowID, lc)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", lc.name, err)
+			}
+			p.record[recordKey(p.record, lc.name)] = val
+		}
+	}
 
 	return records, nil
 }
 
-func (o *OracleConnector) CreateTempTable(originalTable, tempTable string, schema *domain.TableSchema) error {
-	tx, err := o.db.Begin()
+// oracleLOBRowID - служебная колонка с ROWID строки, LOB которой дочитывается частями
+const oracleLOBRowID = "LOB_ROWID"
+
+// oracleLOBChunksPerQuery - количество частей DBMS_LOB.SUBSTR в одном запросе
+const oracleLOBChunksPerQuery = 32
+
+// oracleLOBRead - значения строки, превысившие лимит SQL при truncate
+type oracleLOBRead struct {
+	record  domain.Record
+	rowID   string
+	columns []lobColumn
+}
+
+// readLOBPrefix читает первые max_size байт (символов для CLOB) значения частями
+// в пределах лимитов SQL, не загружая LOB целиком
+func (o *OracleConnector) readLOBPrefix(ctx context.Context, tableName, rowID string, lc lobColumn) (interface{}, error) {
+	blob := strings.Contains(strings.ToUpper(lc.dataType), "BLOB")
+	var data []byte
+	var text strings.Builder
+	for offset := int64(1); offset <= lc.limit.MaxSize; {
+		query, next := oracleLOBChunkQuery(tableName, lc, offset)
+		values, err := o.readLOBChunks(ctx, query, rowID)
+		if err != nil {
+			return nil, fmt.Errorf("read LOB chunk failed: %w", err)
+		}
+		offset = next
+
+		for _, v := range values {
+			switch chunk := v.(type) {
+			case []byte:
+				data = append(data, chunk...)
+			case string:
+				text.WriteString(chunk)
+			case nil:
+				// Значение стало короче, пока читалось
+				offset = lc.limit.MaxSize + 1
+			default:
+				return nil, fmt.Errorf("unexpected LOB chunk type %T", v)
+			}
+		}
+	}
+	if blob {
+		return data, nil
+	}
+	return text.String(), nil
+}
+
+// readLOBChunks выполняет запрос частей LOB одной строки
+func (o *OracleConnector) readLOBChunks(ctx context.Context, query, rowID string) ([]interface{}, error) {
+	rows, err := o.db.QueryContext(ctx, query, rowID)
 	if err != nil {
-		return fmt.Errorf("transaction begin failed: %w", err)
+		return nil, err
 	}
-	defer tx.Rollback()
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("row %s not found", rowID)
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	if err := rows.Scan(valuePtrs...); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// oracleLOBChunkQuery строит запрос очередных частей значения, начиная с offset,
+// и возвращает смещение следующей части. Части не выходят за лимиты SQL
+// (RAW 2000 байт, VARCHAR2 1000 символов) и за max_size колонки
+func oracleLOBChunkQuery(tableName string, lc lobColumn, offset int64) (string, int64) {
+	chunk := int64(oracleMaxSQLVarchar2)
+	if strings.Contains(strings.ToUpper(lc.dataType), "BLOB") {
+		chunk = oracleMaxSQLRaw
+	}
+	var exprs []string
+	for i := 0; i < oracleLOBChunksPerQuery && offset <= lc.limit.MaxSize; i++ {
+		n := min(chunk, lc.limit.MaxSize-offset+1)
+		exprs = append(exprs, fmt.Sprintf("DBMS_LOB.SUBSTR(%s, %d, %d)", lc.name, n, offset))
+		offset += n
+	}
+	return fmt.Sprintf("SELECT %s FROM %s WHERE ROWID = CHARTOROWID(:1)", strings.Join(exprs, ", "), tableName), offset
+}
+
+// Лимиты DBMS_LOB.SUBSTR в SQL: результат для BLOB - RAW до 2000 байт, для CLOB -
+// VARCHAR2 до 4000 байт, то есть до 1000 символов в многобайтовой кодировке
+const (
+	oracleMaxSQLRaw      = 2000
+	oracleMaxSQLVarchar2 = 1000
+)
 
-	// Удаляем временную таблицу если она есть
-	if _, err := tx.Exec(fmt.Sprintf(
-		"BEGIN EXECUTE IMMEDIATE 'DROP TABLE %s'; EXCEPTION WHEN OTHERS THEN NULL; END;",
-		tempTable)); err != nil {
+// oracleLOBDialect: DBMS_LOB.SUBSTR обрезает на сервере только значения в пределах
+// лимитов SQL, при большем max_size значение дочитывается частями (readLOBPrefix)
+var oracleLOBDialect = lobDialect{
+	length: func(col string) string {
+		return fmt.Sprintf("DBMS_LOB.GETLENGTH(%s)", col)
+	},
+	truncate: func(col domain.ColumnInfo, max int64) string {
+		switch t := strings.ToUpper(col.DataType); {
+		case strings.Contains(t, "BLOB") && max <= oracleMaxSQLRaw,
+			strings.Contains(t, "CLOB") && max <= oracleMaxSQLVarchar2:
+			return fmt.Sprintf("DBMS_LOB.SUBSTR(%s, %d, 1)", col.Name, max)
+		}
+		return ""
+	},
+}
+
+// CreateTempTable создает промежуточную таблицу для загрузки.
+// Это обычная таблица: данные GLOBAL TEMPORARY TABLE видны только в сессии,
+// которая их вставила, а соединения пула используют разные сессии
+func (o *OracleConnector) CreateTempTable(originalTable, tempTable string, schema *domain.TableSchema) error {
+	// Удаляем промежуточную таблицу, оставшуюся от прерванного прогона
+	if err := o.DropTable(tempTable); err != nil {
 		return fmt.Errorf("drop temp table failed: %w", err)
 	}
 
-	// Создаем временную табличку
+	var createStmt string
 	if len(schema.Columns) > 0 {
-		// Собираем запрос на  создание временной таблицы
+		// Собираем запрос на создание таблицы
 		var createColumns []string
 		for _, col := range schema.Columns {
 			colDef := fmt.Sprintf("%s %s", col.Name, col.DataType)
@@ -202,34 +395,24 @@ func (o *OracleConnector) CreateTempTable(originalTable, tempTable string, schem
 			createColumns = append(createColumns, fmt.Sprintf("PRIMARY KEY (%s)", schema.PrimaryKey))
 		}
 
-		createStmt := fmt.Sprintf(
-			"CREATE GLOBAL TEMPORARY TABLE %s (%s) ON COMMIT PRESERVE ROWS",
-			tempTable,
-			strings.Join(createColumns, ","))
-
-		if _, err := tx.Exec(createStmt); err != nil {
-			return fmt.Errorf("create temp table failed: %w", err)
-		}
+		createStmt = fmt.Sprintf("CREATE TABLE %s (%s)", tempTable, strings.Join(createColumns, ","))
 	} else {
 		//Создаем пустую копию, если столбцы не указаны
-		createStmt := fmt.Sprintf(
-			"CREATE GLOBAL TEMPORARY TABLE %s ON COMMIT PRESERVE ROWS AS SELECT * FROM %s WHERE 1=0",
-			tempTable,
-			originalTable)
-		if _, err := tx.Exec(createStmt); err != nil {
-			return fmt.Errorf("create temp table failed: %w", err)
-		}
+		createStmt = fmt.Sprintf("CREATE TABLE %s AS SELECT * FROM %s WHERE 1=0", tempTable, originalTable)
 	}
 
-	return tx.Commit()
+	if _, err := o.db.Exec(createStmt); err != nil {
+		return fmt.Errorf("create temp table failed: %w", err)
+	}
+	return nil
 }
 
-func (o *OracleConnector) InsertBatch(tableName string, records []domain.Record, columns []string) error {
+func (o *OracleConnector) InsertBatch(ctx context.Context, tableName string, records []domain.Record, columns []string) error {
 	if len(records) == 0 {
 		return nil
 	}
 
-	tx, err := o.db.Begin()
+	tx, err := o.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("transaction begin failed: %w", err)
 	}
@@ -243,7 +426,7 @@ func (o *OracleConnector) InsertBatch(tableName string, records []domain.Record,
 	}
 
 	// Подготовка пакетной вставки с использованием привязки массива Oracle
-	stmt, err := tx.Prepare(fmt.Sprintf(
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(
 		"INSERT INTO %s (%s) VALUES (%s)",
 		tableName,
 		strings.Join(columns, ","),
@@ -257,41 +440,154 @@ func (o *OracleConnector) InsertBatch(tableName string, records []domain.Record,
 	for _, record := range records {
 		values := make([]interface{}, len(columns))
 		for i, col := range columns {
-			values[i] = record[col]
+			values[i] = o.bindValue(record[col])
 		}
 
-		if _, err := stmt.Exec(values...); err != nil {
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
 			tx.Rollback()
 			return fmt.Errorf("insert failed: %w", err)
 		}
 	}
 
-	return tx.Commit()
+	return commit(tx)
 }
 
-func (o *OracleConnector) SwapTables(originalTable, tempTable string) error {
-	backupTable := originalTable + "_backup_" + time.Now().Format("20060102150405")
+// bindValue готовит значение к привязке. Большие строки и двоичные данные
+// передаются как CLOB/BLOB: драйвер пишет их во временный LOB частями.
+// Decimal передается как NUMBER драйвера: строка с точкой зависела бы
+// от NLS_NUMERIC_CHARACTERS сессии
+func (o *OracleConnector) bindValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case domain.Decimal:
+		if n, err := go_ora.NewNumberFromString(val.String()); err == nil {
+			return n
+		}
+	case string:
+		if len(val) > oracleMaxInlineBind {
+			return go_ora.Clob{String: val, Valid: true}
+		}
+	case []byte:
+		if len(val) > oracleMaxInlineBind {
+			return go_ora.Blob{Data: val}
+		}
+	}
+	return toDBValue(v, o.location())
+}
 
-	tx, err := o.db.Begin()
+// SwapTables публикует tempTable под именем originalTable.
+// Прежняя таблица сохраняется как резервная копия с отметкой времени.
+//
+// DDL в Oracle фиксируется сразу, поэтому транзакция атомарности не дает.
+// Оба переименования выполняются одним PL/SQL блоком на сервере: окно без таблицы
+// сокращается до времени двух операций словаря, а при ошибке второго переименования
+// блок возвращает исходное имя, так что originalTable не пропадает. Если таблицы еще
+// нет (первый запуск), промежуточная таблица просто получает ее имя.
+// Для замены без окна используйте публикацию через синоним или представление
+func (o *OracleConnector) SwapTables(originalTable, tempTable string) error {
+	owner, original := splitOracleName(originalTable)
+	backupName, err := NewBackupName(o, originalTable)
 	if err != nil {
-		return fmt.Errorf("transaction begin failed: %w", err)
+		return err
+	}
+	_, backup := splitOracleName(backupName)
+	_, temp := splitOracleName(tempTable)
+	ownerExpr := "USER"
+	if owner != "" {
+		ownerExpr = fmt.Sprintf("'%s'", strings.ToUpper(owner))
 	}
-	defer tx.Rollback()
 
-	// Oracle не поддерживает прямую замену таблиц, поэтому мы используем операции переименования.
-	renameStmts := []string{
-		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", originalTable, backupTable),
-		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", tempTable, originalTable),
-		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", backupTable, tempTable),
+	block := fmt.Sprintf(`DECLARE
+		  existing NUMBER;
+		BEGIN
+		  SELECT COUNT(*) INTO existing FROM all_tables
+		   WHERE owner = %[1]s AND table_name = '%[2]s';
+		  IF existing > 0 THEN
+		    EXECUTE IMMEDIATE 'ALTER TABLE %[3]s RENAME TO %[4]s';
+		  END IF;
+		  BEGIN
+		    EXECUTE IMMEDIATE 'ALTER TABLE %[5]s RENAME TO %[6]s';
+		  EXCEPTION
+		    WHEN OTHERS THEN
+		      IF existing > 0 THEN
+		        EXECUTE IMMEDIATE 'ALTER TABLE %[7]s RENAME TO %[6]s';
+		      END IF;
+		      RAISE;
+		  END;
+		END;`,
+		ownerExpr, strings.ToUpper(original),
+		originalTable, backup,
+		qualifyOracleName(owner, temp), original,
+		qualifyOracleName(owner, backup))
+
+	if _, err := o.db.Exec(block); err != nil {
+		return fmt.Errorf("swap tables failed: %w", err)
 	}
+	return nil
+}
 
-	for _, stmt := range renameStmts {
-		if _, err := tx.Exec(stmt); err != nil {
-			return fmt.Errorf("rename operation failed: %w", err)
+// splitOracleName разделяет имя вида SCHEMA.TABLE на владельца и имя таблицы
+func splitOracleName(name string) (string, string) {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+func qualifyOracleName(owner, name string) string {
+	if owner == "" {
+		return name
+	}
+	return owner + "." + name
+}
+
+func (o *OracleConnector) ListTables(prefix string) ([]string, error) {
+	// ALTER TABLE ... RENAME оставляет таблицу в схеме владельца, поэтому для
+	// имен со схемой ищем в all_tables и возвращаем имена со схемой
+	owner, namePrefix := splitOracleName(prefix)
+	query := "SELECT table_name FROM user_tables WHERE table_name LIKE :1 ESCAPE '\\'"
+	args := []interface{}{likePrefix(strings.ToUpper(namePrefix), '\\')}
+	if owner != "" {
+		query = "SELECT table_name FROM all_tables WHERE table_name LIKE :1 ESCAPE '\\' AND owner = :2"
+		args = append(args, strings.ToUpper(owner))
+	}
+
+	rows, err := o.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list tables failed: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan table name failed: %w", err)
 		}
+		tables = append(tables, qualifyOracleName(owner, name))
 	}
+	return tables, rows.Err()
+}
 
-	return tx.Commit()
+func (o *OracleConnector) CreateTableIfNotExists(tableName string, schema *domain.TableSchema) error {
+	if schema == nil || len(schema.Columns) == 0 {
+		return fmt.Errorf("schema cannot be empty")
+	}
+	createStmt := fmt.Sprintf("CREATE TABLE %s (%s)", tableName, strings.Join(columnDefinitions(schema, false), ","))
+	// ORA-00955: имя уже используется существующим объектом
+	_, err := o.db.Exec(fmt.Sprintf(
+		`BEGIN
+		   EXECUTE IMMEDIATE '%s';
+		 EXCEPTION
+		   WHEN OTHERS THEN
+		     IF SQLCODE != -955 THEN
+		       RAISE;
+		     END IF;
+		 END;`,
+		strings.ReplaceAll(createStmt, "'", "''")))
+	if err != nil {
+		return fmt.Errorf("create table failed: %w", err)
+	}
+	return nil
 }
 
 func (o *OracleConnector) DropTable(tableName string) error {
@@ -440,9 +736,20 @@ func (o *OracleConnector) ExecuteSelectWithSchema(query string, args ...interfac
 
 	return schema, nil
 }
-func (o *OracleConnector) ExecuteSelect(query string, args ...interface{}) ([]domain.Record, error) {
+
+// ExecuteSelect выполняет запрос, повторяя его при временных ошибках
+func (o *OracleConnector) ExecuteSelect(ctx context.Context, query string, args ...interface{}) ([]domain.Record, error) {
+	var records []domain.Record
+	err := retryStatement(ctx, o.retry, o.db, oracleMaxIdleConns, func() (err error) {
+		records, err = o.executeSelect(ctx, query, args...)
+		return err
+	})
+	return records, err
+}
+
+func (o *OracleConnector) executeSelect(ctx context.Context, query string, args ...interface{}) ([]domain.Record, error) {
 	// Выполняем запрос
-	rows, err := o.db.Query(query, args...)
+	rows, err := o.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query execution failed: %w", err)
 	}
@@ -453,6 +760,7 @@ func (o *OracleConnector) ExecuteSelect(query string, args ...interface{}) ([]do
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
+	colTypes := columnDBTypes(rows)
 
 	// Подготавливаем структуру для результатов
 	var records []domain.Record
@@ -476,15 +784,12 @@ func (o *OracleConnector) ExecuteSelect(query string, args ...interface{}) ([]do
 		for i, colName := range columns {
 			val := values[i].(*interface{})
 
-			// Конвертируем Oracle-specific типы в стандартные
-			switch v := (*val).(type) {
-			case []byte:
-				record[colName] = string(v)
-			case time.Time:
-				record[colName] = v
-			default:
-				record[colName] = v
+			// Конвертируем Oracle-specific типы в стандартные (NUMBER без потери точности)
+			converted, err := convertValue(*val, dbTypeAt(colTypes, i), o.location())
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", colName, err)
 			}
+			record[colName] = converted
 		}
 
 		records = append(records, record)
@@ -497,3 +802,647 @@ func (o *OracleConnector) ExecuteSelect(query string, args ...interface{}) ([]do
 
 	return records, nil
 }
+
+// PublishedTable возвращает таблицу, на которую указывает синоним name
+func (o *OracleConnector) PublishedTable(name string) (string, error) {
+	owner, synonym := splitOracleName(name)
+	query := "SELECT table_owner, table_name FROM user_synonyms WHERE synonym_name = :1"
+	args := []interface{}{strings.ToUpper(synonym)}
+	if owner != "" {
+		query = "SELECT table_owner, table_name FROM all_synonyms WHERE synonym_name = :1 AND owner = :2"
+		args = append(args, strings.ToUpper(owner))
+	}
+
+	rows, err := o.db.Query(query, args...)
+	if err != nil {
+		return "", fmt.Errorf("query synonym failed: %w", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return "", rows.Err()
+	}
+	var tableOwner, table string
+	if err := rows.Scan(&tableOwner, &table); err != nil {
+		return "", fmt.Errorf("scan synonym failed: %w", err)
+	}
+	if owner == "" {
+		return table, nil
+	}
+	return qualifyOracleName(tableOwner, table), nil
+}
+
+// PublishTable переключает синоним name на table. CREATE OR REPLACE SYNONYM атомарен,
+// зависимые объекты и права на таблицы не затрагиваются
+func (o *OracleConnector) PublishTable(name, table string) error {
+	owner, synonym := splitOracleName(name)
+	ownerExpr := "USER"
+	if owner != "" {
+		ownerExpr = fmt.Sprintf("'%s'", strings.ToUpper(owner))
+	}
+	backupName, err := NewBackupName(o, name)
+	if err != nil {
+		return err
+	}
+	_, backup := splitOracleName(backupName)
+
+	// Переход с замены таблиц: прежняя таблица освобождает имя для синонима.
+	// Если синоним создать не удалось, таблица возвращается под прежнее имя
+	block := fmt.Sprintf(`DECLARE
+		  existing NUMBER;
+		BEGIN
+		  SELECT COUNT(*) INTO existing FROM all_tables
+		   WHERE owner = %[1]s AND table_name = '%[2]s';
+		  IF existing > 0 THEN
+		    EXECUTE IMMEDIATE 'ALTER TABLE %[3]s RENAME TO %[4]s';
+		  END IF;
+		  BEGIN
+		    EXECUTE IMMEDIATE 'CREATE OR REPLACE SYNONYM %[3]s FOR %[5]s';
+		  EXCEPTION
+		    WHEN OTHERS THEN
+		      IF existing > 0 THEN
+		        EXECUTE IMMEDIATE 'ALTER TABLE %[6]s RENAME TO %[2]s';
+		      END IF;
+		      RAISE;
+		  END;
+		END;`,
+		ownerExpr, strings.ToUpper(synonym), name, backup, table, qualifyOracleName(owner, backup))
+
+	if _, err := o.db.Exec(block); err != nil {
+		return fmt.Errorf("publish synonym failed: %w", err)
+	}
+	return nil
+}
+
+func (o *OracleConnector) CopyGrants(fromTable, toTable string) error {
+	_, from := splitOracleName(fromTable)
+	rows, err := o.db.Query(
+		"SELECT grantee, privilege, grantable FROM user_tab_privs WHERE table_name = :1 AND grantor = USER",
+		strings.ToUpper(from))
+	if err != nil {
+		return fmt.Errorf("query grants failed: %w", err)
+	}
+	var grants []string
+	for rows.Next() {
+		var grantee, privilege, grantable string
+		if err := rows.Scan(&grantee, &privilege, &grantable); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan grant failed: %w", err)
+		}
+		stmt := fmt.Sprintf("GRANT %s ON %s TO %s", privilege, toTable, grantee)
+		if grantable == "YES" {
+			stmt += " WITH GRANT OPTION"
+		}
+		grants = append(grants, stmt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query grants failed: %w", err)
+	}
+
+	for _, stmt := range grants {
+		if _, err := o.db.Exec(stmt); err != nil {
+			return fmt.Errorf("copy grant failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// PartitionSpec описывает секцию для EXCHANGE PARTITION
+type PartitionSpec struct {
+	Name             string // Имя секции
+	KeyValue         string // SQL-литерал значения ключа секционирования (если имя не задано)
+	IncludingIndexes bool   // Обменивать локальные индексы вместе с данными
+	WithValidation   bool   // Проверять, что строки принадлежат секции
+}
+
+// clause возвращает ссылку на секцию в синтаксисе Oracle
+func (p PartitionSpec) clause() string {
+	if p.Name != "" {
+		return fmt.Sprintf("PARTITION %s", p.Name)
+	}
+	return fmt.Sprintf("PARTITION FOR (%s)", p.KeyValue)
+}
+
+// ExchangePartition заменяет секцию таблицы содержимым промежуточной таблицы.
+// Прежнее содержимое секции остается в промежуточной таблице, которая сохраняется
+// как резервная копия таблицы
+func (o *OracleConnector) ExchangePartition(table, staging string, p PartitionSpec) error {
+	if p.IncludingIndexes {
+		if err := o.createLocalIndexes(table, staging); err != nil {
+			return err
+		}
+	}
+	if err := o.checkExchangeCompatibility(table, staging, p.IncludingIndexes); err != nil {
+		return err
+	}
+	backupName, err := NewBackupName(o, table)
+	if err != nil {
+		return err
+	}
+	_, backup := splitOracleName(backupName)
+
+	stmt := fmt.Sprintf("ALTER TABLE %s EXCHANGE %s WITH TABLE %s", table, p.clause(), staging)
+	if p.IncludingIndexes {
+		stmt += " INCLUDING INDEXES"
+	} else {
+		stmt += " EXCLUDING INDEXES"
+	}
+	if p.WithValidation {
+		stmt += " WITH VALIDATION"
+	} else {
+		stmt += " WITHOUT VALIDATION"
+	}
+	// Глобальные индексы иначе становятся UNUSABLE
+	stmt += " UPDATE GLOBAL INDEXES"
+	if _, err := o.db.Exec(stmt); err != nil {
+		return fmt.Errorf("exchange partition failed: %w", err)
+	}
+
+	if _, err := o.db.Exec(fmt.Sprintf("ALTER TABLE %s RENAME TO %s", staging, backup)); err != nil {
+		return fmt.Errorf("rename exchanged table to backup failed: %w", err)
+	}
+	return nil
+}
+
+// GatherPartitionStats собирает статистику секции после обмена
+func (o *OracleConnector) GatherPartitionStats(table string, p PartitionSpec) error {
+	owner, name := splitOracleName(table)
+	partition := p.Name
+	if partition == "" {
+		// Имя секции по значению ключа определяется по объекту данных любой ее строки
+		rows, err := o.db.Query(fmt.Sprintf(
+			`SELECT o.subobject_name FROM all_objects o
+			  WHERE o.owner = NVL(:1, USER) AND o.object_name = :2 AND o.object_type = 'TABLE PARTITION'
+			    AND o.data_object_id = (SELECT DBMS_ROWID.ROWID_OBJECT(ROWID) FROM %s %s WHERE ROWNUM = 1)`,
+			table, p.clause()), strings.ToUpper(owner), strings.ToUpper(name))
+		if err != nil {
+			return fmt.Errorf("resolve partition name failed: %w", err)
+		}
+		if rows.Next() {
+			err = rows.Scan(&partition)
+		}
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("resolve partition name failed: %w", err)
+		}
+		if partition == "" {
+			// Пустая секция: собирать нечего
+			return nil
+		}
+	}
+
+	ownerExpr := "USER"
+	if owner != "" {
+		ownerExpr = fmt.Sprintf("'%s'", strings.ToUpper(owner))
+	}
+	_, err := o.db.Exec(fmt.Sprintf(
+		`BEGIN
+		   DBMS_STATS.GATHER_TABLE_STATS(ownname => %s, tabname => '%s', partname => '%s', granularity => 'PARTITION');
+		 END;`,
+		ownerExpr, strings.ToUpper(name), strings.ToUpper(partition)))
+	if err != nil {
+		return fmt.Errorf("gather partition stats failed: %w", err)
+	}
+	return nil
+}
+
+// oracleColumn - описание колонки для проверки совместимости таблиц
+type oracleColumn struct {
+	name, dataType, nullable string
+	length, precision, scale int64
+}
+
+func (o *OracleConnector) tableColumns(table string) ([]oracleColumn, error) {
+	owner, name := splitOracleName(table)
+	rows, err := o.db.Query(
+		`SELECT column_name, data_type, data_length, NVL(data_precision, -1), NVL(data_scale, -1), nullable
+		   FROM all_tab_columns WHERE owner = NVL(:1, USER) AND table_name = :2 ORDER BY column_id`,
+		strings.ToUpper(owner), strings.ToUpper(name))
+	if err != nil {
+		return nil, fmt.Errorf("query columns of %s failed: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []oracleColumn
+	for rows.Next() {
+		var c oracleColumn
+		if err := rows.Scan(&c.name, &c.dataType, &c.length, &c.precision, &c.scale, &c.nullable); err != nil {
+			return nil, fmt.Errorf("scan column failed: %w", err)
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// oracleIndex - индекс и его колонки в порядке следования
+type oracleIndex struct {
+	name    string
+	unique  bool
+	columns []string
+}
+
+// indexes возвращает локальные индексы секционированной таблицы
+// (local = true) или все индексы обычной таблицы (local = false)
+func (o *OracleConnector) indexes(table string, local bool) ([]oracleIndex, error) {
+	owner, name := splitOracleName(table)
+	query := `SELECT i.index_name, i.uniqueness, c.column_name
+	            FROM all_indexes i
+	            JOIN all_ind_columns c ON c.index_owner = i.owner AND c.index_name = i.index_name
+	           WHERE i.table_owner = NVL(:1, USER) AND i.table_name = :2`
+	if local {
+		query += ` AND EXISTS (SELECT 1 FROM all_part_indexes p
+		                        WHERE p.owner = i.owner AND p.index_name = i.index_name AND p.locality = 'LOCAL')`
+	}
+	query += " ORDER BY i.index_name, c.column_position"
+
+	rows, err := o.db.Query(query, strings.ToUpper(owner), strings.ToUpper(name))
+	if err != nil {
+		return nil, fmt.Errorf("query indexes of %s failed: %w", table, err)
+	}
+	defer rows.Close()
+
+	var result []oracleIndex
+	for rows.Next() {
+		var indexName, uniqueness, column string
+		if err := rows.Scan(&indexName, &uniqueness, &column); err != nil {
+			return nil, fmt.Errorf("scan index failed: %w", err)
+		}
+		if n := len(result); n == 0 || result[n-1].name != indexName {
+			result = append(result, oracleIndex{name: indexName, unique: uniqueness == "UNIQUE"})
+		}
+		last := &result[len(result)-1]
+		last.columns = append(last.columns, column)
+	}
+	return result, rows.Err()
+}
+
+// createLocalIndexes создает на промежуточной таблице индексы, соответствующие
+// локальным индексам секционированной таблицы. Индексы строятся после загрузки
+func (o *OracleConnector) createLocalIndexes(table, staging string) error {
+	local, err := o.indexes(table, true)
+	if err != nil {
+		return err
+	}
+	existing, err := o.indexes(staging, false)
+	if err != nil {
+		return err
+	}
+	for i, idx := range local {
+		if hasIndex(existing, idx) {
+			continue
+		}
+		kind := "INDEX"
+		if idx.unique {
+			kind = "UNIQUE INDEX"
+		}
+		stmt := fmt.Sprintf("CREATE %s %s ON %s (%s)", kind, oracleIndexName(i+1), staging, strings.Join(idx.columns, ", "))
+		if _, err := o.db.Exec(stmt); err != nil {
+			return fmt.Errorf("create index for exchange failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func hasIndex(indexes []oracleIndex, want oracleIndex) bool {
+	for _, idx := range indexes {
+		if idx.unique == want.unique && strings.Join(idx.columns, ",") == strings.Join(want.columns, ",") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkExchangeCompatibility проверяет, что промежуточная таблица совпадает с
+// секционированной по колонкам и (при обмене индексов) по локальным индексам
+func (o *OracleConnector) checkExchangeCompatibility(table, staging string, includingIndexes bool) error {
+	target, err := o.tableColumns(table)
+	if err != nil {
+		return err
+	}
+	stage, err := o.tableColumns(staging)
+	if err != nil {
+		return err
+	}
+	if len(target) != len(stage) {
+		return fmt.Errorf("exchange incompatible: %s has %d columns, %s has %d", table, len(target), staging, len(stage))
+	}
+	for i := range target {
+		if target[i] != stage[i] {
+			return fmt.Errorf("exchange incompatible: column %d differs: %s %s(%d,%d,%d) null=%s vs %s %s(%d,%d,%d) null=%s",
+				i+1, target[i].name, target[i].dataType, target[i].length, target[i].precision, target[i].scale, target[i].nullable,
+				stage[i].name, stage[i].dataType, stage[i].length, stage[i].precision, stage[i].scale, stage[i].nullable)
+		}
+	}
+
+	if !includingIndexes {
+		return nil
+	}
+	local, err := o.indexes(table, true)
+	if err != nil {
+		return err
+	}
+	existing, err := o.indexes(staging, false)
+	if err != nil {
+		return err
+	}
+	for _, idx := range local {
+		if !hasIndex(existing, idx) {
+			return fmt.Errorf("exchange incompatible: %s has no index matching local index %s (%s)",
+				staging, idx.name, strings.Join(idx.columns, ", "))
+		}
+	}
+	return nil
+}
+
+// oracleIndexName возвращает уникальное имя индекса. Имена индексов в Oracle уникальны
+// в схеме и не меняются при переименовании таблицы, поэтому имя не может зависеть
+// от имени промежуточной таблицы: после замены она становится резервной копией
+func oracleIndexName(n int) string {
+	return fmt.Sprintf("IX_%s_%d", strings.ToUpper(strconv.FormatInt(time.Now().UnixNano(), 36)), n)
+}
+
+// BuildIndexes создает первичный ключ и индексы схемы после загрузки данных
+func (o *OracleConnector) BuildIndexes(ctx context.Context, tableName string, schema *domain.TableSchema, progress func(done, total int, index string)) error {
+	total := len(schema.Indexes)
+	if schema.PrimaryKey != "" {
+		total++
+	}
+	done := 0
+	if schema.PrimaryKey != "" {
+		if _, err := o.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s)", tableName, schema.PrimaryKey)); err != nil {
+			return fmt.Errorf("add primary key failed: %w", err)
+		}
+		done++
+		progress(done, total, "PRIMARY KEY ("+schema.PrimaryKey+")")
+	}
+	for _, index := range schema.Indexes {
+		if _, err := o.db.ExecContext(ctx, fmt.Sprintf("CREATE INDEX %s ON %s (%s)", oracleIndexName(done+1), tableName, index)); err != nil {
+			return fmt.Errorf("create index %s failed: %w", index, err)
+		}
+		done++
+		progress(done, total, index)
+	}
+	return nil
+}
+
+// AnalyzeTable собирает статистику таблицы для оптимизатора
+func (o *OracleConnector) AnalyzeTable(ctx context.Context, tableName string) error {
+	owner, name := splitOracleName(tableName)
+	ownerExpr := "USER"
+	if owner != "" {
+		ownerExpr = fmt.Sprintf("'%s'", strings.ToUpper(owner))
+	}
+	_, err := o.db.ExecContext(ctx, fmt.Sprintf(
+		"BEGIN DBMS_STATS.GATHER_TABLE_STATS(ownname => %s, tabname => '%s'); END;",
+		ownerExpr, strings.ToUpper(name)))
+	if err != nil {
+		return fmt.Errorf("gather table stats failed: %w", err)
+	}
+	return nil
+}
+
+// CloneMetadata переносит с fromTable на toTable внешние ключи, CHECK-ограничения,
+// триггеры, комментарии и права
+func (o *OracleConnector) CloneMetadata(ctx context.Context, fromTable, toTable string) (*MetadataReport, error) {
+	report := &MetadataReport{}
+	steps := []func(context.Context, string, string, *MetadataReport) error{
+		o.cloneForeignKeys,
+		o.cloneChecks,
+		o.cloneTriggers,
+		o.cloneComments,
+	}
+	for _, step := range steps {
+		if err := step(ctx, fromTable, toTable, report); err != nil {
+			return report, err
+		}
+	}
+	if err := o.CopyGrants(fromTable, toTable); err != nil {
+		report.skipped("grants", fromTable, err)
+	} else {
+		report.copied("grants", "of "+fromTable)
+	}
+	return report, nil
+}
+
+func (o *OracleConnector) cloneForeignKeys(ctx context.Context, fromTable, toTable string, report *MetadataReport) error {
+	_, from := splitOracleName(fromTable)
+	rows, err := o.db.QueryContext(ctx, `
+		SELECT c.constraint_name, cc.column_name, r.table_name, rc.column_name, c.delete_rule
+		  FROM user_constraints c
+		  JOIN user_cons_columns cc ON cc.constraint_name = c.constraint_name
+		  JOIN all_constraints r ON r.owner = c.r_owner AND r.constraint_name = c.r_constraint_name
+		  JOIN all_cons_columns rc ON rc.owner = r.owner AND rc.constraint_name = r.constraint_name
+		                          AND rc.position = cc.position
+		 WHERE c.table_name = :1 AND c.constraint_type = 'R' AND c.status = 'ENABLED'
+		 ORDER BY c.constraint_name, cc.position`, strings.ToUpper(from))
+	if err != nil {
+		return fmt.Errorf("query foreign keys failed: %w", err)
+	}
+	type foreignKey struct {
+		name, refTable, onDelete string
+		columns, refColumns      []string
+	}
+	var keys []*foreignKey
+	for rows.Next() {
+		var name, column, refTable, refColumn, onDelete string
+		if err := rows.Scan(&name, &column, &refTable, &refColumn, &onDelete); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan foreign key failed: %w", err)
+		}
+		if n := len(keys); n == 0 || keys[n-1].name != name {
+			keys = append(keys, &foreignKey{name: name, refTable: refTable, onDelete: onDelete})
+		}
+		fk := keys[len(keys)-1]
+		fk.columns = append(fk.columns, column)
+		fk.refColumns = append(fk.refColumns, refColumn)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query foreign keys failed: %w", err)
+	}
+
+	for _, fk := range keys {
+		desc := fmt.Sprintf("(%s) -> %s(%s)", strings.Join(fk.columns, ","), fk.refTable, strings.Join(fk.refColumns, ","))
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD FOREIGN KEY (%s) REFERENCES %s (%s)",
+			toTable, strings.Join(fk.columns, ", "), fk.refTable, strings.Join(fk.refColumns, ", "))
+		switch fk.onDelete {
+		case "CASCADE":
+			stmt += " ON DELETE CASCADE"
+		case "SET NULL":
+			stmt += " ON DELETE SET NULL"
+		}
+		if _, err := o.db.ExecContext(ctx, stmt); err != nil {
+			report.skipped("foreign key", desc, err)
+			continue
+		}
+		report.copied("foreign key", desc)
+	}
+
+	// Внешние ключи других таблиц продолжают ссылаться на прежнюю таблицу
+	childRows, err := o.db.QueryContext(ctx, `
+		SELECT c.table_name, c.constraint_name
+		  FROM user_constraints c
+		  JOIN user_constraints p ON p.constraint_name = c.r_constraint_name
+		 WHERE c.constraint_type = 'R' AND p.table_name = :1`, strings.ToUpper(from))
+	if err != nil {
+		return fmt.Errorf("query referencing foreign keys failed: %w", err)
+	}
+	var children []string
+	for childRows.Next() {
+		var table, name string
+		if err := childRows.Scan(&table, &name); err != nil {
+			childRows.Close()
+			return fmt.Errorf("scan referencing foreign key failed: %w", err)
+		}
+		children = append(children, table+"."+name)
+	}
+	childRows.Close()
+	for _, c := range children {
+		report.skipped("referencing foreign key", c, "other tables keep referencing the previous table")
+	}
+	return childRows.Err()
+}
+
+func (o *OracleConnector) cloneChecks(ctx context.Context, fromTable, toTable string, report *MetadataReport) error {
+	_, from := splitOracleName(fromTable)
+	// search_condition_vc есть только с 12.2, поэтому читается LONG search_condition.
+	// LONG нельзя сравнивать в SQL, поэтому системные ограничения NOT NULL
+	// (они переносятся определением колонок) отбрасываются при чтении
+	rows, err := o.db.QueryContext(ctx, `
+		SELECT constraint_name, generated, search_condition FROM user_constraints
+		 WHERE table_name = :1 AND constraint_type = 'C' AND status = 'ENABLED'`,
+		strings.ToUpper(from))
+	if err != nil {
+		return fmt.Errorf("query check constraints failed: %w", err)
+	}
+	type check struct{ name, condition string }
+	var checks []check
+	for rows.Next() {
+		var c check
+		var generated string
+		if err := rows.Scan(&c.name, &generated, &c.condition); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan check constraint failed: %w", err)
+		}
+		if generated == "GENERATED NAME" && strings.HasSuffix(strings.TrimSpace(c.condition), " IS NOT NULL") {
+			continue
+		}
+		checks = append(checks, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query check constraints failed: %w", err)
+	}
+
+	for _, c := range checks {
+		// Имена ограничений уникальны в схеме, поэтому имя генерирует Oracle
+		if _, err := o.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD CHECK (%s)", toTable, c.condition)); err != nil {
+			report.skipped("check", c.name, err)
+			continue
+		}
+		report.copied("check", c.name)
+	}
+	return nil
+}
+
+func (o *OracleConnector) cloneTriggers(ctx context.Context, fromTable, toTable string, report *MetadataReport) error {
+	_, from := splitOracleName(fromTable)
+	rows, err := o.db.QueryContext(ctx, `
+		SELECT trigger_name, description, NVL(when_clause, ' '), trigger_body FROM user_triggers
+		 WHERE table_name = :1 AND base_object_type = 'TABLE' AND status = 'ENABLED'`,
+		strings.ToUpper(from))
+	if err != nil {
+		return fmt.Errorf("query triggers failed: %w", err)
+	}
+	type trigger struct{ name, description, when, body string }
+	var triggers []trigger
+	for rows.Next() {
+		var t trigger
+		if err := rows.Scan(&t.name, &t.description, &t.when, &t.body); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan trigger failed: %w", err)
+		}
+		triggers = append(triggers, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query triggers failed: %w", err)
+	}
+
+	// description: "<имя>\nBEFORE INSERT ON <таблица>\nFOR EACH ROW\n"
+	onTable := regexp.MustCompile(`(?i)\bON\s+("?[\w$#]+"?\.)?"?` + regexp.QuoteMeta(from) + `"?`)
+	for _, t := range triggers {
+		header := t.description
+		if i := strings.IndexAny(header, " \n"); i >= 0 {
+			header = header[i:]
+		}
+		if !onTable.MatchString(header) {
+			report.skipped("trigger", t.name, "cannot parse trigger description")
+			continue
+		}
+		header = onTable.ReplaceAllLiteralString(header, "ON "+toTable)
+		name := strings.ToUpper(versionedName(t.name))
+		stmt := fmt.Sprintf("CREATE TRIGGER %s %s", name, strings.TrimSpace(header))
+		if strings.TrimSpace(t.when) != "" {
+			stmt += fmt.Sprintf(" WHEN (%s)", t.when)
+		}
+		stmt += "\n" + t.body
+		if _, err := o.db.ExecContext(ctx, stmt); err != nil {
+			report.skipped("trigger", t.name, err)
+			continue
+		}
+		report.copied("trigger", t.name+" as "+name)
+	}
+	return nil
+}
+
+func (o *OracleConnector) cloneComments(ctx context.Context, fromTable, toTable string, report *MetadataReport) error {
+	_, from := splitOracleName(fromTable)
+	var stmts, names []string
+
+	rows, err := o.db.QueryContext(ctx,
+		"SELECT comments FROM user_tab_comments WHERE table_name = :1 AND comments IS NOT NULL",
+		strings.ToUpper(from))
+	if err != nil {
+		return fmt.Errorf("query table comment failed: %w", err)
+	}
+	for rows.Next() {
+		var comment string
+		if err := rows.Scan(&comment); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan table comment failed: %w", err)
+		}
+		stmts = append(stmts, fmt.Sprintf("COMMENT ON TABLE %s IS %s", toTable, quoteString(comment)))
+		names = append(names, toTable)
+	}
+	rows.Close()
+
+	rows, err = o.db.QueryContext(ctx,
+		"SELECT column_name, comments FROM user_col_comments WHERE table_name = :1 AND comments IS NOT NULL",
+		strings.ToUpper(from))
+	if err != nil {
+		return fmt.Errorf("query column comments failed: %w", err)
+	}
+	for rows.Next() {
+		var column, comment string
+		if err := rows.Scan(&column, &comment); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan column comment failed: %w", err)
+		}
+		stmts = append(stmts, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", toTable, column, quoteString(comment)))
+		names = append(names, column)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query column comments failed: %w", err)
+	}
+
+	for i, stmt := range stmts {
+		if _, err := o.db.ExecContext(ctx, stmt); err != nil {
+			report.skipped("comment", names[i], err)
+			continue
+		}
+		report.copied("comment", names[i])
+	}
+	return nil
+}
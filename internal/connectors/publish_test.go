@@ -0,0 +1,138 @@
+package connectors
+
+import (
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestOraclePublishTable(t *testing.T) {
+	tests := []struct {
+		name  string
+		table string
+		want  []string // Фрагменты PL/SQL-блока
+	}{
+		{"own schema", "SIMS", []string{
+			"WHERE owner = USER AND table_name = 'SIMS'",
+			"EXECUTE IMMEDIATE 'ALTER TABLE SIMS RENAME TO SIMS_bk_",
+			"EXECUTE IMMEDIATE 'CREATE OR REPLACE SYNONYM SIMS FOR SIMS_B'",
+			"EXECUTE IMMEDIATE 'ALTER TABLE SIMS_bk_",
+		}},
+		{"qualified", "app.sims", []string{
+			"WHERE owner = 'APP' AND table_name = 'SIMS'",
+			"EXECUTE IMMEDIATE 'ALTER TABLE app.sims RENAME TO sims_bk_",
+			"EXECUTE IMMEDIATE 'CREATE OR REPLACE SYNONYM app.sims FOR SIMS_B'",
+			// Восстанавливается таблица в схеме владельца
+			"EXECUTE IMMEDIATE 'ALTER TABLE app.sims_bk_",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := &scriptDB{}
+			o := &OracleConnector{db: script.open(t)}
+			if err := o.PublishTable(tt.table, "SIMS_B"); err != nil {
+				t.Fatalf("PublishTable: %v", err)
+			}
+			execs := script.executed()
+			if len(execs) != 1 {
+				t.Fatalf("executed %d statements, want one block: %q", len(execs), execs)
+			}
+			block := execs[0]
+			for _, part := range tt.want {
+				if !strings.Contains(block, part) {
+					t.Errorf("block has no %q:\n%s", part, block)
+				}
+			}
+			// Таблица возвращается под прежним именем, только если синоним не создан
+			rename := strings.Index(block, "RENAME TO SIMS';")
+			if rename < 0 || rename < strings.Index(block, "WHEN OTHERS THEN") {
+				t.Errorf("restore rename is not in the exception handler:\n%s", block)
+			}
+		})
+	}
+
+	script := &scriptDB{}
+	script.fail("CREATE OR REPLACE SYNONYM", errors.New("ORA-00955: name is already used by an existing object"))
+	o := &OracleConnector{db: script.open(t)}
+	if err := o.PublishTable("SIMS", "SIMS_B"); err == nil {
+		t.Error("PublishTable with failing block succeeded")
+	}
+}
+
+func TestMariaDBPublishTable(t *testing.T) {
+	backup := regexp.MustCompile(`^sims_bk_\d{14}$`)
+	tests := []struct {
+		name       string
+		baseTables int64
+		renameErr  error
+		want       []string // Ожидаемые команды; "<backup>" - имя резервной копии
+		wantErr    bool
+	}{
+		{"view exists", 0, nil, []string{
+			"CREATE OR REPLACE VIEW sims AS SELECT * FROM sims_b",
+		}, false},
+		{"switch from table", 1, nil, []string{
+			"CREATE OR REPLACE VIEW sims_publish AS SELECT * FROM sims_b",
+			"RENAME TABLE sims TO <backup>, sims_publish TO sims",
+		}, false},
+		{"rename failed", 1, errors.New("Error 1050: Table 'sims' already exists"), []string{
+			"CREATE OR REPLACE VIEW sims_publish AS SELECT * FROM sims_b",
+			"DROP VIEW IF EXISTS sims_publish",
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := &scriptDB{}
+			script.on("table_type = 'BASE TABLE'", []string{"count"}, []driver.Value{tt.baseTables})
+			if tt.renameErr != nil {
+				script.fail("RENAME TABLE", tt.renameErr)
+			}
+			m := &MariaDBConnector{db: script.open(t)}
+
+			err := m.PublishTable("sims", "sims_b")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PublishTable() = %v, wantErr %t", err, tt.wantErr)
+			}
+			if tt.renameErr != nil && !errors.Is(err, tt.renameErr) {
+				t.Errorf("PublishTable() = %v, want wrapped %v", err, tt.renameErr)
+			}
+			execs := script.executed()
+			if len(execs) != len(tt.want) {
+				t.Fatalf("executed %q, want %q", execs, tt.want)
+			}
+			for i, want := range tt.want {
+				got := execs[i]
+				if strings.Contains(want, "<backup>") {
+					fields := strings.Fields(got)
+					if len(fields) < 5 || !backup.MatchString(strings.TrimSuffix(fields[4], ",")) {
+						t.Errorf("statement %d = %q, want backup name in %q", i, got, want)
+						continue
+					}
+					got = strings.Replace(got, strings.TrimSuffix(fields[4], ","), "<backup>", 1)
+				}
+				if got != want {
+					t.Errorf("statement %d = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestViewSourceTable(t *testing.T) {
+	tests := []struct {
+		definition string
+		want       string
+	}{
+		{"select `db`.`sims_a`.`id` AS `id` from `db`.`sims_a`", "sims_a"},
+		{"SELECT `sims_b`.`id` AS `id` FROM `sims_b`", "sims_b"},
+		{"select `db`.`t`.`from_date` AS `from_date` from `db`.`sims_c` where 1", "sims_c"},
+		{"select 1 AS `x`", ""},
+	}
+	for _, tt := range tests {
+		if got := viewSourceTable(tt.definition); got != tt.want {
+			t.Errorf("viewSourceTable(%q) = %q, want %q", tt.definition, got, tt.want)
+		}
+	}
+}
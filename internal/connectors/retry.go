@@ -0,0 +1,83 @@
+package connectors
+
+import (
+	"context"
+	"database/sql"
+	"db_swapper/internal/config"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy - повтор операции при временных ошибках с экспоненциальной паузой
+type RetryPolicy struct {
+	Attempts   int // Всего попыток, включая первую; 1 - без повторов
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// NewRetryPolicy строит политику из конфига. nil - без повторов
+func NewRetryPolicy(cfg *config.RetryConfig) RetryPolicy {
+	if cfg == nil {
+		return RetryPolicy{Attempts: 1}
+	}
+	p := RetryPolicy{Attempts: cfg.Attempts, Backoff: cfg.Backoff, MaxBackoff: cfg.MaxBackoff}
+	if p.Attempts == 0 {
+		p.Attempts = 3
+	}
+	if p.Backoff == 0 {
+		p.Backoff = time.Second
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = time.Minute
+	}
+	return p
+}
+
+// Do выполняет op, повторяя ее при временных ошибках. Перед каждым повтором
+// вызывается onRetry (может быть nil) с номером неудачной попытки и паузой.
+// Отмена ctx прерывает паузу и возвращает последнюю ошибку
+func (p RetryPolicy) Do(ctx context.Context, op func() error, onRetry func(attempt int, err error, wait time.Duration)) error {
+	wait := p.Backoff
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= p.Attempts || !IsTransient(err) || ctx.Err() != nil {
+			return err
+		}
+		// Случайная часть паузы разводит повторы параллельных задач
+		sleep := wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+		if onRetry != nil {
+			onRetry(attempt, err, sleep)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(sleep):
+		}
+		if wait *= 2; wait > p.MaxBackoff {
+			wait = p.MaxBackoff
+		}
+	}
+}
+
+// retryStatement повторяет читающий запрос коннектора до отмены ctx. После потери соединения
+// закрываются простаивающие соединения пула: database/sql отбрасывает сломанное
+// соединение только после ошибки на нем, и без сброса повтор мог бы снова взять
+// соединение, оборванное тем же сбоем
+func retryStatement(ctx context.Context, policy RetryPolicy, db *sql.DB, maxIdle int, op func() error) error {
+	return policy.Do(ctx, op, func(attempt int, err error, wait time.Duration) {
+		if Classify(err) == ErrorConnection {
+			db.SetMaxIdleConns(0)
+			db.SetMaxIdleConns(maxIdle)
+		}
+	})
+}
+
+// commit фиксирует транзакцию. Ошибку COMMIT повторять нельзя: при обрыве
+// соединения неизвестно, сохранены ли данные, и повтор может их задвоить
+func commit(tx *sql.Tx) error {
+	if err := tx.Commit(); err != nil {
+		return Permanent(fmt.Errorf("commit failed: %w", err))
+	}
+	return nil
+}
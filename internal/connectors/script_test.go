@@ -0,0 +1,118 @@
+package connectors
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// scriptDB - БД для тестов генерируемого DDL: запоминает выполненные команды и
+// отвечает на запросы заготовленными строками. Запрос и команда сопоставляются
+// с заготовкой по подстроке текста, первая подходящая побеждает
+type scriptDB struct {
+	mu      sync.Mutex
+	results []scriptResult
+	fails   map[string]error // Ошибки команд по подстроке текста
+	execs   []string
+}
+
+// scriptResult - ответ на запросы, содержащие match
+type scriptResult struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+}
+
+// on задает ответ на запросы, содержащие match
+func (s *scriptDB) on(match string, columns []string, rows ...[]driver.Value) {
+	s.results = append(s.results, scriptResult{match: match, columns: columns, rows: rows})
+}
+
+// fail задает ошибку команд, содержащих match
+func (s *scriptDB) fail(match string, err error) {
+	if s.fails == nil {
+		s.fails = make(map[string]error)
+	}
+	s.fails[match] = err
+}
+
+// executed возвращает выполненные команды
+func (s *scriptDB) executed() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.execs...)
+}
+
+// open возвращает пул соединений с этой БД
+func (s *scriptDB) open(t *testing.T) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(scriptConnector{s})
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+type scriptConnector struct{ db *scriptDB }
+
+func (c scriptConnector) Connect(context.Context) (driver.Conn, error) {
+	return &scriptConn{db: c.db}, nil
+}
+
+func (c scriptConnector) Driver() driver.Driver { return nil }
+
+type scriptConn struct{ db *scriptDB }
+
+func (c *scriptConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *scriptConn) Close() error { return nil }
+
+func (c *scriptConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *scriptConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for match, err := range c.db.fails {
+		if strings.Contains(query, match) {
+			return nil, err
+		}
+	}
+	c.db.execs = append(c.db.execs, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *scriptConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, r := range c.db.results {
+		if strings.Contains(query, r.match) {
+			return &scriptRows{columns: r.columns, rows: r.rows}, nil
+		}
+	}
+	return &scriptRows{}, nil
+}
+
+type scriptRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *scriptRows) Columns() []string { return r.columns }
+
+func (r *scriptRows) Close() error { return nil }
+
+func (r *scriptRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
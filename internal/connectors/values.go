@@ -0,0 +1,150 @@
+package connectors
+
+import (
+	"database/sql"
+	"db_swapper/internal/domain"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// isDecimalType сообщает, что тип колонки требует точного десятичного представления
+func isDecimalType(dbType string) bool {
+	switch strings.ToUpper(dbType) {
+	case "NUMBER", "DECIMAL", "NEWDECIMAL", "NUMERIC":
+		return true
+	}
+	return false
+}
+
+// isBinaryType сообщает, что колонка хранит двоичные данные и должна остаться []byte
+func isBinaryType(dbType string) bool {
+	switch strings.ToUpper(dbType) {
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "RAW", "LONG RAW", "BFILE":
+		return true
+	}
+	return false
+}
+
+// isZonedType сообщает, что значение колонки хранит пояс или момент времени
+// (TIMESTAMP WITH TIME ZONE / WITH LOCAL TIME ZONE): go-ora возвращает такие
+// значения уже с поясом, и переводить показания часов не нужно
+func isZonedType(dbType string) bool {
+	t := strings.ToUpper(dbType)
+	if strings.Contains(t, "TIME ZONE") {
+		return true
+	}
+	switch t {
+	case "TIMESTAMPTZ", "TIMESTAMPTZ_DTY", "TIMESTAMPELTZ", "TIMESTAMPLTZ_DTY", "TIMETZ":
+		return true
+	}
+	return false
+}
+
+// isLOBType сообщает, что по колонке нельзя сортировать (Oracle не сравнивает LOB)
+func isLOBType(dbType string) bool {
+	switch t := strings.ToUpper(dbType); {
+	case strings.Contains(t, "LOB"), strings.Contains(t, "TEXT"), t == "LONG", t == "LONG RAW", t == "BFILE", t == "XMLTYPE":
+		return true
+	}
+	return false
+}
+
+// batchOrder возвращает порядок чтения пачек. Без стабильного порядка соседние
+// пачки OFFSET могут пересекаться или пропускать строки: сортируем по ключу
+// схемы, а без ключа - по всем колонкам, кроме LOB
+func batchOrder(schema *domain.TableSchema) string {
+	if schema.PrimaryKey != "" {
+		return schema.PrimaryKey
+	}
+	var columns []string
+	for _, col := range schema.Columns {
+		if !isLOBType(col.DataType) {
+			columns = append(columns, col.Name)
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+// columnDBTypes возвращает имена типов колонок результата в том же порядке, что и rows.Columns()
+func columnDBTypes(rows *sql.Rows) []string {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil
+	}
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.DatabaseTypeName()
+	}
+	return names
+}
+
+// convertValue приводит значение, полученное от драйвера, к типам domain.Record.
+// Дата/время без пояса считаются показаниями часов в поясе подключения loc,
+// значения колонок с поясом возвращаются как есть
+func convertValue(v interface{}, dbType string, loc *time.Location) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if isDecimalType(dbType) {
+		var d domain.Decimal
+		if err := d.Scan(v); err != nil {
+			return nil, fmt.Errorf("decimal conversion failed: %w", err)
+		}
+		return d, nil
+	}
+
+	switch val := v.(type) {
+	case []byte:
+		if isBinaryType(dbType) {
+			return val, nil
+		}
+		return string(val), nil
+	case time.Time:
+		if isZonedType(dbType) {
+			return val, nil
+		}
+		return domain.WallClockIn(val, loc), nil
+	case string, int64, float64, bool, domain.Decimal:
+		return val, nil
+	default:
+		return fmt.Sprintf("%v", val), nil
+	}
+}
+
+// dbTypeAt безопасно возвращает тип колонки по индексу
+func dbTypeAt(types []string, i int) string {
+	if i < len(types) {
+		return types[i]
+	}
+	return ""
+}
+
+// toDBValue готовит значение записи к передаче драйверу:
+// момент времени переводится в показания часов пояса подключения loc
+func toDBValue(v interface{}, loc *time.Location) interface{} {
+	if t, ok := v.(time.Time); ok {
+		return domain.NaiveWallClock(t, loc)
+	}
+	return v
+}
+
+// columnDefinitions собирает определения колонок и первичного ключа для CREATE TABLE
+func columnDefinitions(schema *domain.TableSchema, withAutoIncrement bool) []string {
+	var defs []string
+	for _, col := range schema.Columns {
+		colDef := fmt.Sprintf("%s %s", col.Name, col.DataType)
+		if !col.IsNullable {
+			colDef += " NOT NULL"
+		}
+		if withAutoIncrement && col.AutoIncrement {
+			colDef += " AUTO_INCREMENT"
+		}
+		defs = append(defs, colDef)
+	}
+	if schema.PrimaryKey != "" {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", schema.PrimaryKey))
+	}
+	return defs
+}
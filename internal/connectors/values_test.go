@@ -0,0 +1,118 @@
+package connectors
+
+import (
+	"db_swapper/internal/domain"
+	"testing"
+	"time"
+
+	go_ora "github.com/sijms/go-ora/v2"
+)
+
+func TestConvertValueDecimal(t *testing.T) {
+	tests := []struct {
+		name   string
+		dbType string
+		src    interface{}
+		want   string
+	}{
+		{"go-ora NUMBER ICCID", "NUMBER", "89701012345678901234", "89701012345678901234"},
+		{"go-ora NUMBER negative scale", "NUMBER", "-1234.5600", "-1234.56"},
+		{"go-ora NUMBER exponent", "NUMBER", "1.2E+25", "12000000000000000000000000"},
+		{"mysql DECIMAL ICCID", "DECIMAL", []byte("89701012345678901234"), "89701012345678901234"},
+		{"mysql NEWDECIMAL", "NEWDECIMAL", []byte("-0.000123"), "-0.000123"},
+		{"lowercase type", "decimal", []byte("42.10"), "42.1"},
+		{"int64 in NUMBER", "NUMBER", int64(8970101234567890123), "8970101234567890123"},
+	}
+	for _, tt := range tests {
+		got, err := convertValue(tt.src, tt.dbType, time.UTC)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		d, ok := got.(domain.Decimal)
+		if !ok {
+			t.Errorf("%s: got %T, want domain.Decimal", tt.name, got)
+			continue
+		}
+		if d.String() != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, d, tt.want)
+		}
+	}
+
+	if _, err := convertValue("12,5", "NUMBER", time.UTC); err == nil {
+		t.Error("convertValue(12,5): expected error")
+	}
+	if v, err := convertValue(nil, "NUMBER", time.UTC); v != nil || err != nil {
+		t.Errorf("convertValue(nil) = %v, %v", v, err)
+	}
+}
+
+// Значение, прочитанное из одной СУБД, записывается в другую и читается обратно без изменений
+func TestDecimalRoundTripAcrossDialects(t *testing.T) {
+	for _, in := range []string{"89701012345678901234", "-0.000000000001", "12345678901234567890.12345678"} {
+		// Oracle -> MariaDB: go-ora отдает строку, MySQL возвращает []byte
+		fromOracle, err := convertValue(in, "NUMBER", time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bound := toDBValue(fromOracle, time.UTC)
+		s, err := bound.(domain.Decimal).Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromMariaDB, err := convertValue([]byte(s.(string)), "DECIMAL", time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fromMariaDB.(domain.Decimal).Equal(fromOracle.(domain.Decimal)) {
+			t.Errorf("Oracle -> MariaDB %s: got %v", in, fromMariaDB)
+		}
+
+		// MariaDB -> Oracle: значение привязывается как NUMBER драйвера, без строки с разделителем
+		o := &OracleConnector{}
+		n, ok := o.bindValue(fromMariaDB).(*go_ora.Number)
+		if !ok {
+			t.Fatalf("Oracle bind of %s: got %T, want *go_ora.Number", in, o.bindValue(fromMariaDB))
+		}
+		str, err := n.String()
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := convertValue(str, "NUMBER", time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !back.(domain.Decimal).Equal(fromMariaDB.(domain.Decimal)) {
+			t.Errorf("MariaDB -> Oracle %s: got %v", in, back)
+		}
+	}
+}
+
+func TestConvertValueTime(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*3600)
+	naive := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	zoned := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("+05", 5*3600))
+
+	tests := []struct {
+		name   string
+		dbType string
+		src    time.Time
+		want   time.Time
+	}{
+		{"oracle DATE", "DATE", naive, time.Date(2024, 3, 1, 12, 0, 0, 0, moscow)},
+		{"mysql DATETIME", "DATETIME", naive, time.Date(2024, 3, 1, 12, 0, 0, 0, moscow)},
+		{"oracle TIMESTAMP WITH TIME ZONE", "TimeStampTZ_DTY", zoned, zoned},
+		{"oracle TIMESTAMP WITH LOCAL TIME ZONE", "TimeStampLTZ_DTY", zoned, zoned},
+		{"config type", "TIMESTAMP(6) WITH TIME ZONE", zoned, zoned},
+	}
+	for _, tt := range tests {
+		got, err := convertValue(tt.src, tt.dbType, moscow)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !got.(time.Time).Equal(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal хранит число произвольной точности без потерь (NUMBER, DECIMAL).
+// Значение представлено целым unscaled и количеством знаков после запятой scale:
+// value = unscaled * 10^-scale
+type Decimal struct {
+	unscaled big.Int
+	scale    int
+}
+
+// ParseDecimal разбирает десятичную строку вида "-123.4500", "1E+5", "19.2e-3"
+func ParseDecimal(s string) (Decimal, error) {
+	var d Decimal
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return d, fmt.Errorf("empty decimal string")
+	}
+
+	exp := 0
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		e, err := strconv.Atoi(str[i+1:])
+		if err != nil {
+			return d, fmt.Errorf("invalid decimal exponent %q: %w", s, err)
+		}
+		exp = e
+		str = str[:i]
+	}
+
+	sign := ""
+	if str != "" && (str[0] == '-' || str[0] == '+') {
+		if str[0] == '-' {
+			sign = "-"
+		}
+		str = str[1:]
+	}
+
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+	digits := intPart + fracPart
+	if digits == "" {
+		return d, fmt.Errorf("invalid decimal %q", s)
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return d, fmt.Errorf("invalid decimal %q", s)
+		}
+	}
+
+	if _, ok := d.unscaled.SetString(sign+digits, 10); !ok {
+		return d, fmt.Errorf("invalid decimal %q", s)
+	}
+	d.scale = len(fracPart) - exp
+	if d.scale < 0 {
+		d.unscaled.Mul(&d.unscaled, pow10(-d.scale))
+		d.scale = 0
+	}
+	return d, nil
+}
+
+// MustParseDecimal как ParseDecimal, но паникует при ошибке (для констант и тестов)
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewDecimalFromInt создает Decimal из целого числа
+func NewDecimalFromInt(v int64) Decimal {
+	var d Decimal
+	d.unscaled.SetInt64(v)
+	return d
+}
+
+// Scale возвращает количество знаков после запятой
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// IsInteger сообщает, что у числа нет дробной части
+func (d Decimal) IsInteger() bool {
+	if d.scale == 0 {
+		return true
+	}
+	var rem big.Int
+	rem.Rem(&d.unscaled, pow10(d.scale))
+	return rem.Sign() == 0
+}
+
+// Cmp сравнивает числа без учета представления (1.50 == 1.5)
+func (d Decimal) Cmp(other Decimal) int {
+	a, b := new(big.Int).Set(&d.unscaled), new(big.Int).Set(&other.unscaled)
+	switch {
+	case d.scale < other.scale:
+		a.Mul(a, pow10(other.scale-d.scale))
+	case d.scale > other.scale:
+		b.Mul(b, pow10(d.scale-other.scale))
+	}
+	return a.Cmp(b)
+}
+
+// Equal сообщает, что числа равны по значению
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// String возвращает каноническое представление без экспоненты и лишних нулей в дробной части
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(&d.unscaled).String()
+	sign := ""
+	if d.unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+	intPart := digits[:len(digits)-d.scale]
+	fracPart := strings.TrimRight(digits[len(digits)-d.scale:], "0")
+	if fracPart == "" {
+		if intPart == "0" {
+			sign = ""
+		}
+		return sign + intPart
+	}
+	return sign + intPart + "." + fracPart
+}
+
+// Float64 возвращает приближенное значение (с возможной потерей точности)
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Value реализует driver.Valuer: число передается строкой, которую
+// и Oracle (NUMBER), и MariaDB (DECIMAL) принимают без потери точности
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan реализует sql.Scanner
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case Decimal:
+		*d = v
+		return nil
+	case string:
+		parsed, err := ParseDecimal(v)
+		if err != nil {
+			return err
+		}
+		*d = parsed
+		return nil
+	case []byte:
+		return d.Scan(string(v))
+	case int64:
+		*d = NewDecimalFromInt(v)
+		return nil
+	case float64:
+		return d.Scan(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		return fmt.Errorf("cannot scan %T into Decimal", src)
+	}
+}
+
+// MarshalText реализует encoding.TextMarshaler (JSON, YAML)
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  string
+		scale int
+	}{
+		{"0", "0", 0},
+		{"123", "123", 0},
+		{"-123.4500", "-123.45", 4},
+		{"+7.5", "7.5", 1},
+		{".5", "0.5", 1},
+		{"-0.001", "-0.001", 3},
+		{"-0.000", "0", 3},
+		{"8970101234567890123", "8970101234567890123", 0},   // ICCID, 19 знаков
+		{"89701012345678901234", "89701012345678901234", 0}, // ICCID, 20 знаков
+		{"-99999999999999999999999999999999999999", "-99999999999999999999999999999999999999", 0},
+		{"12345678901234567890.123456789012345678", "12345678901234567890.123456789012345678", 18},
+		{"1E+5", "100000", 0},
+		{"1e5", "100000", 0},
+		{"19.2e-3", "0.0192", 4},
+		{"-2.5E-2", "-0.025", 3},
+		{"8.9701012345678901234E19", "89701012345678901234", 0},
+	}
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", tt.in, err)
+			continue
+		}
+		if got := d.String(); got != tt.want {
+			t.Errorf("ParseDecimal(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+		if d.Scale() != tt.scale {
+			t.Errorf("ParseDecimal(%q).Scale() = %d, want %d", tt.in, d.Scale(), tt.scale)
+		}
+		// Каноническая строка разбирается в то же значение
+		again, err := ParseDecimal(d.String())
+		if err != nil || !again.Equal(d) {
+			t.Errorf("round trip of %q: got %v, %v", tt.in, again, err)
+		}
+	}
+}
+
+func TestParseDecimalInvalid(t *testing.T) {
+	for _, in := range []string{"", " ", "-", ".", "1.2.3", "12a", "1e", "1e+x", "0x10", "1,5"} {
+		if _, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q): expected error", in)
+		}
+	}
+}
+
+func TestDecimalCmp(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.50", "1.5", 0},
+		{"1E2", "100.000", 0},
+		{"-1", "1", -1},
+		{"89701012345678901235", "89701012345678901234", 1},
+		{"0.1", "0.10000000000000000001", -1},
+	}
+	for _, tt := range tests {
+		if got := MustParseDecimal(tt.a).Cmp(MustParseDecimal(tt.b)); got != tt.want {
+			t.Errorf("Cmp(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalScan(t *testing.T) {
+	tests := []struct {
+		name string
+		src  interface{}
+		want string
+	}{
+		{"oracle string", "89701012345678901234", "89701012345678901234"},
+		{"oracle negative", "-12.340", "-12.34"},
+		{"oracle exponent", "1.5E-7", "0.00000015"},
+		{"mysql bytes", []byte("89701012345678901234"), "89701012345678901234"},
+		{"mysql decimal bytes", []byte("-0012345.6700"), "-12345.67"},
+		{"int64", int64(-9223372036854775808), "-9223372036854775808"},
+		{"float64", float64(0.25), "0.25"},
+		{"decimal", MustParseDecimal("7.70"), "7.7"},
+		{"nil", nil, "0"},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		if err := d.Scan(tt.src); err != nil {
+			t.Errorf("%s: Scan(%v): %v", tt.name, tt.src, err)
+			continue
+		}
+		if got := d.String(); got != tt.want {
+			t.Errorf("%s: Scan(%v) = %q, want %q", tt.name, tt.src, got, tt.want)
+		}
+	}
+
+	var d Decimal
+	if err := d.Scan(true); err == nil {
+		t.Error("Scan(bool): expected error")
+	}
+	if err := d.Scan("abc"); err == nil {
+		t.Error(`Scan("abc"): expected error`)
+	}
+}
+
+// Значение, записанное через Value, читается обратно через Scan без потерь
+// независимо от того, вернул ли драйвер строку (go-ora) или []byte (MySQL)
+func TestDecimalValueRoundTrip(t *testing.T) {
+	for _, in := range []string{"0", "-1", "89701012345678901234", "-123.456789012345678901", "1E+30", "0.000000000000000000000001"} {
+		d := MustParseDecimal(in)
+		v, err := d.Value()
+		if err != nil {
+			t.Fatalf("Value(%s): %v", in, err)
+		}
+		s, ok := v.(string)
+		if !ok {
+			t.Fatalf("Value(%s) = %T, want string", in, v)
+		}
+		for _, src := range []interface{}{s, []byte(s)} {
+			var back Decimal
+			if err := back.Scan(src); err != nil {
+				t.Errorf("Scan(%T %q): %v", src, s, err)
+				continue
+			}
+			if !back.Equal(d) || back.String() != d.String() {
+				t.Errorf("round trip of %s via %T: got %s", in, src, back)
+			}
+		}
+	}
+}
+
+func TestDecimalText(t *testing.T) {
+	d := MustParseDecimal("-89701012345678901234.5")
+	text, err := d.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Decimal
+	if err := back.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if !back.Equal(d) {
+		t.Errorf("text round trip: got %s, want %s", back, d)
+	}
+}
+
+func TestDecimalIsInteger(t *testing.T) {
+	for in, want := range map[string]bool{"10": true, "10.000": true, "10.5": false, "1E3": true, "-0.0001": false} {
+		if got := MustParseDecimal(in).IsInteger(); got != want {
+			t.Errorf("IsInteger(%s) = %t, want %t", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Поведение при превышении размера LOB-значения
+const (
+	LOBTruncate = "truncate" // Обрезать значение до MaxSize
+	LOBSkipRow  = "skip_row" // Пропустить строку целиком
+	LOBFail     = "fail"     // Прервать синхронизацию
+)
+
+// ErrLOBTooLarge возвращается коннектором при политике LOBFail
+var ErrLOBTooLarge = errors.New("lob value exceeds max size")
+
+// LOBLimit ограничивает размер значения колонки (байты, для CLOB/TEXT - символы)
+type LOBLimit struct {
+	MaxSize  int64
+	OnExceed string
+}
+
+// OversizedValue подставляется коннектором вместо значения, превысившего лимит
+// при политике LOBSkipRow. Само значение из БД не читается
+type OversizedValue struct {
+	Column string
+	Size   int64
+}
+
+func (v OversizedValue) String() string {
+	return fmt.Sprintf("<oversized %s: %d>", v.Column, v.Size)
+}
+
+// HasOversized сообщает, что запись содержит значение, превысившее лимит
+func (r Record) HasOversized() bool {
+	for _, v := range r {
+		if _, ok := v.(OversizedValue); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// TruncateLOB обрезает строку (по символам) или []byte (по байтам) до max
+func TruncateLOB(v interface{}, max int64) interface{} {
+	switch val := v.(type) {
+	case []byte:
+		if int64(len(val)) > max {
+			return val[:max]
+		}
+	case string:
+		if int64(utf8.RuneCountInString(val)) > max {
+			runes := []rune(val)
+			return string(runes[:max])
+		}
+	}
+	return v
+}
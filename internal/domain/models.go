@@ -17,6 +17,7 @@ type ColumnInfo struct {
 	DataType      string
 	IsNullable    bool
 	AutoIncrement bool
+	LOBLimit      *LOBLimit // Ограничение размера для LOB-колонок источника
 }
 
 func (c *ColumnInfo) GetColumnName(isMapping bool) string {
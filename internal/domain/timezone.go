@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoadLocation загружает часовой пояс по имени IANA ("Europe/Moscow", "UTC").
+// Пустое имя означает локальный пояс сервера
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// WallClockIn интерпретирует показания часов t (без учета его пояса) как время в поясе loc.
+// Используется для типов без пояса (Oracle DATE, MariaDB DATETIME).
+// time.Date корректно учитывает переходы на летнее/зимнее время
+func WallClockIn(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(),
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// NaiveWallClock переводит момент времени в пояс loc и возвращает показания часов
+// с поясом UTC, чтобы драйвер записал их в колонку без пояса без дополнительного сдвига
+func NaiveWallClock(t time.Time, loc *time.Location) time.Time {
+	return WallClockIn(t.In(loc), time.UTC)
+}
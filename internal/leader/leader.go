@@ -0,0 +1,174 @@
+package leader
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"fmt"
+	"logger"
+	"os"
+	"sync"
+	"time"
+)
+
+// Status - состояние выборов для логов и статуса
+type Status struct {
+	Instance  string    `json:"instance"`
+	Leader    string    `json:"leader"`
+	IsLeader  bool      `json:"is_leader"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Elector выбирает лидера среди экземпляров db_swapper через строку аренды
+// в служебной таблице. Лидер продлевает аренду каждые heartbeat, остальные
+// экземпляры забирают ее, когда она истекла
+type Elector struct {
+	conn   connectors.DatabaseConnector
+	cfg    config.LeaderConfig
+	id     string
+	logger *logger.Log
+
+	mu        sync.Mutex
+	leader    string
+	isLeader  bool
+	expiresAt time.Time
+	lastSeen  string // Последний лидер, о котором писали в лог
+}
+
+func New(conn connectors.DatabaseConnector, cfg config.LeaderConfig, l *logger.Log) *Elector {
+	id := cfg.Instance
+	if id == "" {
+		host, _ := os.Hostname()
+		id = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+	return &Elector{conn: conn, cfg: cfg, id: id, logger: l}
+}
+
+// Instance возвращает идентификатор этого экземпляра
+func (e *Elector) Instance() string {
+	return e.id
+}
+
+// Status возвращает текущее состояние выборов
+func (e *Elector) Status() Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return Status{Instance: e.id, Leader: e.leader, IsLeader: e.isLeader, ExpiresAt: e.expiresAt}
+}
+
+// Run участвует в выборах до отмены ctx. Пока экземпляр лидер, выполняется lead
+// с контекстом срока лидерства, который отменяется при потере лидерства или отмене ctx.
+// lead должен вернуться только после завершения всей работы срока: до этого экземпляр
+// не избирается снова, а при остановке продолжает продлевать аренду. Аренда
+// освобождается после возврата lead
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) error {
+	if err := connectors.EnsureLeaseTable(e.conn, e.cfg.LeaseTable()); err != nil {
+		return err
+	}
+
+	var (
+		current     *term // nil, пока экземпляр не лидер
+		lastRenewed time.Time
+	)
+	stepDown := func(reason string) {
+		if current == nil || current.ending {
+			return
+		}
+		e.logger.Info(fmt.Sprintf("Leadership ending (%s), stopping scheduler", reason))
+		current.ending = true
+		current.cancel()
+	}
+
+	ticker := time.NewTicker(e.cfg.HeartbeatInterval())
+	defer ticker.Stop()
+	for {
+		if ctx.Err() != nil && current == nil {
+			if err := e.conn.ReleaseLease(e.cfg.LeaseTable(), e.cfg.LeaseName(), e.id); err != nil {
+				e.logger.Error(fmt.Sprintf("Failed to release leadership: %v", err))
+			}
+			return nil
+		}
+
+		lease, err := e.conn.AcquireLease(e.cfg.LeaseTable(), e.cfg.LeaseName(), e.id, e.cfg.LeaseTimeout())
+		switch {
+		case err != nil:
+			e.logger.Error(fmt.Sprintf("Leader election failed: %v", err))
+			// Без продления аренда скоро достанется другому экземпляру. Лидерство
+			// слагается за heartbeat до истечения, чтобы синхронизации успели прерваться
+			if current != nil && time.Since(lastRenewed) >= e.cfg.LeaseTimeout()-e.cfg.HeartbeatInterval() {
+				stepDown("lease not renewed")
+			}
+		case lease.HeldBy(e.id):
+			lastRenewed = time.Now()
+			e.setLease(lease, true)
+			if current == nil && ctx.Err() == nil {
+				e.logger.Info(fmt.Sprintf("Instance %s elected leader until %s", e.id, lease.ExpiresAt.Format(time.RFC3339)))
+				current = startTerm(ctx, lead)
+			}
+		default:
+			e.setLease(lease, false)
+			if current != nil {
+				stepDown(fmt.Sprintf("lease taken by %s", lease.Holder))
+			} else if e.changedLeader(lease.Holder) {
+				e.logger.Info(fmt.Sprintf("Standby: current leader is %s, lease expires at %s", lease.Holder, lease.ExpiresAt.Format(time.RFC3339)))
+			}
+		}
+
+		var termDone <-chan struct{}
+		if current != nil {
+			termDone = current.done
+		}
+		shutdown := ctx.Done()
+		if current != nil && current.ending {
+			// Остановка уже начата, ждем завершения срока
+			shutdown = nil
+		}
+		select {
+		case <-shutdown:
+			stepDown("shutdown")
+		case <-termDone:
+			current = nil
+			e.mu.Lock()
+			e.isLeader = false
+			e.mu.Unlock()
+		case <-ticker.C:
+		}
+	}
+}
+
+// term - период лидерства, в котором выполняется lead
+type term struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	ending bool // Контекст срока отменен, lead завершает работу
+}
+
+func startTerm(ctx context.Context, lead func(ctx context.Context)) *term {
+	leadCtx, cancel := context.WithCancel(ctx)
+	t := &term{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(t.done)
+		lead(leadCtx)
+	}()
+	return t
+}
+
+func (e *Elector) setLease(lease *connectors.Lease, isLeader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.isLeader = isLeader
+	e.expiresAt = lease.ExpiresAt
+	e.leader = lease.Holder
+}
+
+// changedLeader запоминает лидера, которого видел резервный экземпляр,
+// чтобы писать в лог только смену лидера
+func (e *Elector) changedLeader(holder string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lastSeen == holder {
+		return false
+	}
+	e.lastSeen = holder
+	return true
+}
@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@every 0s",
+		"@every soon",
+	}
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := ParseCron(expr, time.UTC); err == nil {
+				t.Errorf("ParseCron(%q) succeeded", expr)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatalf("parse %q: %v", s, err)
+		}
+		return v
+	}
+	// 2025-01-01 - среда
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want []string
+	}{
+		{"every minute", "* * * * *", "2025-01-01 10:00", []string{"2025-01-01 10:01", "2025-01-01 10:02"}},
+		{"daily", "30 2 * * *", "2025-01-01 02:30", []string{"2025-01-02 02:30", "2025-01-03 02:30"}},
+		{"step", "*/20 * * * *", "2025-01-01 10:05", []string{"2025-01-01 10:20", "2025-01-01 10:40", "2025-01-01 11:00"}},
+		{"start with step", "5/20 * * * *", "2025-01-01 10:00", []string{"2025-01-01 10:05", "2025-01-01 10:25", "2025-01-01 10:45"}},
+		{"list and range", "0 8-9,18 * * *", "2025-01-01 08:30", []string{"2025-01-01 09:00", "2025-01-01 18:00", "2025-01-02 08:00"}},
+		{"names", "0 12 * jan-feb mon-fri", "2025-01-03 13:00", []string{"2025-01-06 12:00", "2025-01-07 12:00"}},
+		{"sunday as 7", "0 0 * * 7", "2025-01-01 00:00", []string{"2025-01-05 00:00", "2025-01-12 00:00"}},
+		{"day of month or day of week", "0 0 1 * mon", "2025-01-01 00:00", []string{"2025-01-06 00:00", "2025-01-13 00:00", "2025-01-20 00:00", "2025-01-27 00:00", "2025-02-01 00:00"}},
+		{"day of month with star step", "0 0 */2 * mon", "2025-01-01 00:00", []string{"2025-01-13 00:00", "2025-01-27 00:00", "2025-02-03 00:00"}},
+		{"day of week with star step", "0 0 1 * */2", "2025-01-01 00:00", []string{"2025-02-01 00:00", "2025-03-01 00:00", "2025-04-01 00:00"}},
+		{"leap day", "0 0 29 2 *", "2025-01-01 00:00", []string{"2028-02-29 00:00"}},
+		{"monthly descriptor", "@monthly", "2025-01-15 00:00", []string{"2025-02-01 00:00", "2025-03-01 00:00"}},
+		{"weekly descriptor", "@weekly", "2025-01-01 00:00", []string{"2025-01-05 00:00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.expr, time.UTC)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			next := at(tt.from)
+			for _, w := range tt.want {
+				next = s.Next(next)
+				if !next.Equal(at(w)) {
+					t.Fatalf("Next = %s, want %s", next.Format("2006-01-02 15:04"), w)
+				}
+			}
+		})
+	}
+}
+
+func TestCronEvery(t *testing.T) {
+	s, err := ParseCron("@every 90s", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2025, 1, 1, 10, 0, 10, 0, time.UTC)
+	if got := s.Next(from); !got.Equal(from.Add(90 * time.Second)) {
+		t.Errorf("Next = %s, want %s", got, from.Add(90*time.Second))
+	}
+}
+
+func TestCronTimeZone(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skipf("time zone data: %v", err)
+	}
+	s, err := ParseCron("30 2 * * *", moscow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 02:30 по Москве - 23:30 UTC предыдущего дня
+	got := s.Next(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	if want := time.Date(2025, 1, 1, 23, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got.UTC(), want)
+	}
+}
+
+func TestCronDaylightSaving(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data: %v", err)
+	}
+	utc := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2025, month, day, hour, min, 0, 0, time.UTC)
+	}
+	// 30 марта 2025 часы переводятся с 02:00 CET на 03:00 CEST (01:00 UTC),
+	// 26 октября - с 03:00 CEST на 02:00 CET (01:00 UTC)
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time
+	}{
+		{"gap skips missing time", "30 2 * * *", utc(3, 29, 12, 0), []time.Time{utc(3, 31, 0, 30)}},
+		{"gap keeps later time", "0 3 * * *", utc(3, 29, 12, 0), []time.Time{utc(3, 30, 1, 0), utc(3, 31, 1, 0)}},
+		{"gap steps over missing hour", "*/30 * * * *", utc(3, 30, 0, 0), []time.Time{utc(3, 30, 0, 30), utc(3, 30, 1, 0), utc(3, 30, 1, 30)}},
+		{"overlap runs once", "30 2 * * *", utc(10, 25, 12, 0), []time.Time{utc(10, 26, 1, 30), utc(10, 27, 1, 30)}},
+		{"overlap steps through repeated hour once", "0 * * * *", utc(10, 25, 22, 30), []time.Time{utc(10, 25, 23, 0), utc(10, 26, 1, 0), utc(10, 26, 2, 0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseCron(tt.expr, berlin)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			next := tt.from
+			for _, w := range tt.want {
+				next = s.Next(next)
+				if !next.Equal(w) {
+					t.Fatalf("Next = %s (%s), want %s", next.UTC(), next, w)
+				}
+			}
+		})
+	}
+}
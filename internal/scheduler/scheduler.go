@@ -0,0 +1,574 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"logger"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job - задача планировщика
+type Job struct {
+	Name       string
+	Schedule   Schedule
+	Jitter     time.Duration                   // Случайная задержка запуска от 0 до Jitter
+	RunOnStart bool                            // Запустить сразу после старта планировщика
+	Run        func(ctx context.Context) error // ctx отменяется при аварийной остановке (Abort)
+	Resources  []string                        // Ресурсы (подключения к БД), ограничивающие одновременные запуски
+	LockKey    string                          // Задачи с одинаковым ключом (целевая таблица) не выполняются одновременно
+	Overlap    string                          // Что делать с запуском, пока выполняется предыдущий: OverlapSkip (по умолчанию) или OverlapQueue
+	DependsOn  []string                        // Задачи, после успешного завершения которых запускается эта задача (Schedule не используется)
+
+	upstream  map[string]bool // Результаты вышестоящих задач в текущем цикле
+	planned   time.Time       // Время по расписанию (без задержки)
+	fireAt    time.Time       // Время запуска с учетом задержки
+	running   bool            // Запущена или ожидает в очереди
+	pending   bool            // Запуск отложен до завершения пересекающейся задачи
+	queued    bool
+	resources []string
+	lastRun   time.Time
+	lastErr   error
+}
+
+// Политики пересечения запусков
+const (
+	OverlapSkip  = "skip"  // Пропустить запуск
+	OverlapQueue = "queue" // Выполнить после завершения текущего запуска (не более одного отложенного)
+)
+
+// Ошибки ручного запуска (Trigger)
+var (
+	ErrUnknownJob = errors.New("unknown job")
+	ErrJobBusy    = errors.New("job is already running")
+	ErrNotRunning = errors.New("scheduler is not running")
+)
+
+// ErrSkipped возвращается из Job.Run, если запуск пропущен без ошибки (например,
+// таблицу уже загружает другой экземпляр). Такой запуск не считается неуспешным:
+// зависимые задачи не пропускаются, а ждут следующего цикла
+var ErrSkipped = errors.New("run skipped")
+
+// JobInfo - состояние задачи для отображения
+type JobInfo struct {
+	Name      string
+	Next      time.Time
+	Running   bool
+	Queued    bool
+	LastRun   time.Time
+	LastErr   error
+	DependsOn []string
+}
+
+// Scheduler запускает задачи по расписанию из одной горутины и знает время
+// следующего запуска каждой задачи. Задача не запускается повторно, пока
+// не завершился ее предыдущий запуск
+type Scheduler struct {
+	logger *logger.Log
+
+	maxConcurrent  int            // 0 - без ограничения
+	resourceLimits map[string]int // Ограничения по ресурсам
+
+	mu       sync.Mutex
+	jobs     []*Job
+	queue    []*Job          // Задачи, ожидающие свободного места, в порядке постановки
+	active   int             // Выполняющиеся задачи
+	inUse    map[string]int  // Выполняющиеся задачи по ресурсам
+	locks    map[string]*Job // Выполняющиеся задачи по LockKey
+	stopping bool
+	wake     chan struct{}
+	wg       sync.WaitGroup
+
+	runCtx context.Context // Контекст выполняющихся задач
+	abort  context.CancelFunc
+}
+
+// Option настраивает планировщик
+type Option func(*Scheduler)
+
+// WithMaxConcurrent ограничивает общее количество одновременно выполняющихся задач
+func WithMaxConcurrent(n int) Option {
+	return func(s *Scheduler) {
+		s.maxConcurrent = n
+	}
+}
+
+// WithResourceLimit ограничивает количество одновременно выполняющихся задач,
+// использующих ресурс
+func WithResourceLimit(resource string, n int) Option {
+	return func(s *Scheduler) {
+		if n > 0 {
+			s.resourceLimits[resource] = n
+		}
+	}
+}
+
+func New(l *logger.Log, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		logger:         l,
+		resourceLimits: make(map[string]int),
+		inUse:          make(map[string]int),
+		locks:          make(map[string]*Job),
+		wake:           make(chan struct{}, 1),
+		stopping:       true, // До Start задачи не запускаются
+	}
+	s.runCtx, s.abort = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add регистрирует задачу. Можно вызывать и после Start
+func (s *Scheduler) Add(job *Job) error {
+	if job.Run == nil {
+		return fmt.Errorf("job %s: run function is required", job.Name)
+	}
+	if job.Schedule == nil && len(job.DependsOn) == 0 {
+		return fmt.Errorf("job %s: schedule or depends_on is required", job.Name)
+	}
+	now := time.Now()
+	s.mu.Lock()
+	if len(job.DependsOn) > 0 {
+		// Зависимая задача запускается только по завершении вышестоящих
+		job.planned = time.Time{}
+	} else if job.RunOnStart {
+		job.planned = now
+	} else {
+		job.planned = job.Schedule.Next(now)
+	}
+	job.fireAt = job.planned
+	if !job.RunOnStart {
+		job.fireAt = s.withJitter(job)
+	}
+	s.jobs = append(s.jobs, job)
+	s.mu.Unlock()
+
+	s.notify()
+	return nil
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) withJitter(job *Job) time.Time {
+	if job.Jitter <= 0 || job.planned.IsZero() {
+		return job.planned
+	}
+	return job.planned.Add(time.Duration(rand.Int63n(int64(job.Jitter))))
+}
+
+// Start выполняет задачи до отмены ctx. Запущенные задачи не прерываются,
+// дождаться их можно через Wait. После остановки планировщик можно запустить снова
+// (например, при повторном избрании лидером): просроченные задачи запустятся сразу
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.stopping = false
+	// Результаты вышестоящих задач прошлого запуска к новым циклам не относятся
+	for _, job := range s.jobs {
+		job.upstream = nil
+	}
+	// Каждый запуск (срок лидерства) выполняет задачи в своем контексте: Abort
+	// после потери лидерства не должен отменять задачи следующего срока.
+	// Задачи прошлого срока, если они еще выполняются, прерываются
+	s.abort()
+	s.runCtx, s.abort = context.WithCancel(context.Background())
+	s.mu.Unlock()
+	for {
+		wait := s.dispatch(time.Now())
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.stop()
+			return
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// dispatch запускает наступившие задачи и возвращает время до следующего запуска
+func (s *Scheduler) dispatch(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wait := time.Hour
+	for _, job := range s.jobs {
+		if job.fireAt.IsZero() {
+			continue
+		}
+		if !job.fireAt.After(now) {
+			s.launch(job)
+			// Следующий запуск считается от времени по расписанию, чтобы задержка не накапливалась
+			next := job.Schedule.Next(job.planned)
+			if !next.After(now) {
+				next = job.Schedule.Next(now)
+			}
+			job.planned = next
+			job.fireAt = s.withJitter(job)
+			if job.fireAt.IsZero() {
+				s.logger.Error(fmt.Sprintf("Job %s has no next run time", job.Name))
+				continue
+			}
+			s.logger.Info(fmt.Sprintf("Job %s next run at %s", job.Name, job.fireAt.Format(time.RFC3339)))
+		}
+		if d := job.fireAt.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// Trigger запускает задачу вне расписания. Запуск подчиняется тем же ограничениям,
+// что и запуск по расписанию: при нехватке места задача ждет в очереди, при
+// пересечении с выполняющейся синхронизацией таблицы - пропускается (ErrJobBusy)
+// или откладывается по Overlap. Время следующего запуска по расписанию не меняется,
+// зависимые задачи запускаются после успешного завершения как обычно
+func (s *Scheduler) Trigger(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopping {
+		return ErrNotRunning
+	}
+	for _, job := range s.jobs {
+		if !strings.EqualFold(job.Name, name) {
+			continue
+		}
+		if (job.running || s.locked(job)) && job.Overlap != OverlapQueue {
+			return fmt.Errorf("job %s: %w", job.Name, ErrJobBusy)
+		}
+		s.logger.Info(fmt.Sprintf("Job %s triggered manually", job.Name))
+		s.launch(job)
+		return nil
+	}
+	return fmt.Errorf("job %s: %w", name, ErrUnknownJob)
+}
+
+// TriggerAll запускает вне расписания все задачи, не зависящие от других задач.
+// Возвращает имена запущенных задач
+func (s *Scheduler) TriggerAll() ([]string, error) {
+	s.mu.Lock()
+	var names []string
+	for _, job := range s.jobs {
+		if len(job.DependsOn) == 0 {
+			names = append(names, job.Name)
+		}
+	}
+	s.mu.Unlock()
+
+	var triggered []string
+	for _, name := range names {
+		if err := s.Trigger(name); err != nil {
+			if errors.Is(err, ErrNotRunning) {
+				return nil, err
+			}
+			s.logger.Info(fmt.Sprintf("Manual run skipped: %v", err))
+			continue
+		}
+		triggered = append(triggered, name)
+	}
+	return triggered, nil
+}
+
+// stop снимает с очереди задачи, которые еще не начали выполняться
+func (s *Scheduler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopping = true
+	if len(s.queue) > 0 {
+		s.logger.Info(fmt.Sprintf("%d queued jobs dropped on shutdown", len(s.queue)))
+	}
+	dropped := s.queue
+	s.queue = nil
+	for _, job := range dropped {
+		job.running = false
+		job.queued = false
+	}
+	for _, job := range dropped {
+		s.resetDependents(job.Name)
+	}
+}
+
+// launch ставит задачу в очередь и запускает задачи, для которых есть место.
+// Если задача или задача с тем же LockKey еще выполняется, запуск пропускается
+// или откладывается до ее завершения в зависимости от Overlap
+func (s *Scheduler) launch(job *Job) {
+	if s.stopping {
+		return
+	}
+	if job.running || s.locked(job) {
+		if job.Overlap != OverlapQueue {
+			s.logger.Info(fmt.Sprintf("Job %s overlaps a running sync of the same table, run skipped", job.Name))
+			s.resetDependents(job.Name)
+			return
+		}
+		if !job.pending {
+			job.pending = true
+			s.logger.Info(fmt.Sprintf("Job %s overlaps a running sync of the same table, run deferred", job.Name))
+		}
+		return
+	}
+	job.running = true
+	job.queued = true
+	job.resources = uniqueResources(job.Resources)
+	s.queue = append(s.queue, job)
+	s.drain()
+	if job.queued {
+		s.logger.Info(fmt.Sprintf("Job %s queued, queue depth %d", job.Name, len(s.queue)))
+	}
+}
+
+// drain запускает задачи из очереди в порядке постановки. Задача, для которой
+// нет места, не дает более поздним задачам занять те же ресурсы
+func (s *Scheduler) drain() {
+	blocked := make(map[string]bool)
+	var waiting []*Job
+	for _, job := range s.queue {
+		if s.stopping || s.maxConcurrent > 0 && s.active >= s.maxConcurrent || !s.available(job, blocked) {
+			for _, r := range job.resources {
+				blocked[r] = true
+			}
+			waiting = append(waiting, job)
+			continue
+		}
+		s.start(job)
+	}
+	s.queue = waiting
+}
+
+// locked сообщает, что LockKey задачи занят другой задачей
+func (s *Scheduler) locked(job *Job) bool {
+	holder, ok := s.locks[job.LockKey]
+	return job.LockKey != "" && ok && holder != job
+}
+
+func (s *Scheduler) available(job *Job, blocked map[string]bool) bool {
+	if s.locked(job) {
+		return false
+	}
+	for _, r := range job.resources {
+		if blocked[r] {
+			return false
+		}
+		if limit, ok := s.resourceLimits[r]; ok && s.inUse[r] >= limit {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Scheduler) start(job *Job) {
+	job.queued = false
+	job.lastRun = time.Now()
+	s.active++
+	for _, r := range job.resources {
+		s.inUse[r]++
+	}
+	if job.LockKey != "" {
+		s.locks[job.LockKey] = job
+	}
+	// Контекст берется под блокировкой: Start следующего срока заменяет его
+	ctx := s.runCtx
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		err := job.Run(ctx)
+		skipped := errors.Is(err, ErrSkipped)
+		switch {
+		case skipped:
+			s.logger.Info(fmt.Sprintf("Job %s skipped: %v", job.Name, err))
+			err = nil
+		case err != nil:
+			s.logger.Error(fmt.Sprintf("Job %s failed: %v", job.Name, err))
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		job.running = false
+		job.lastErr = err
+		s.active--
+		for _, r := range job.resources {
+			s.inUse[r]--
+		}
+		if job.LockKey != "" {
+			delete(s.locks, job.LockKey)
+		}
+		if skipped {
+			s.resetDependents(job.Name)
+		} else {
+			s.completed(job.Name, err == nil)
+		}
+		s.resumePending()
+		s.drain()
+	}()
+}
+
+// resumePending запускает отложенные задачи, которые больше ни с чем не пересекаются
+func (s *Scheduler) resumePending() {
+	for _, job := range s.jobs {
+		if job.pending && !job.running && !s.locked(job) {
+			job.pending = false
+			s.launch(job)
+		}
+	}
+}
+
+func uniqueResources(resources []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, r := range resources {
+		if r != "" && !seen[r] {
+			seen[r] = true
+			unique = append(unique, r)
+		}
+	}
+	return unique
+}
+
+// QueueDepth возвращает количество задач, ожидающих свободного места
+func (s *Scheduler) QueueDepth() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// completed передает результат задачи зависимым задачам. Зависимая задача
+// запускается, когда в текущем цикле успешно завершились все вышестоящие;
+// если хотя бы одна завершилась с ошибкой, задача и все ниже по графу пропускаются
+func (s *Scheduler) completed(name string, ok bool) {
+	for _, job := range s.jobs {
+		if !dependsOn(job, name) {
+			continue
+		}
+		if job.upstream == nil {
+			job.upstream = make(map[string]bool)
+		}
+		job.upstream[name] = ok
+		if len(job.upstream) < len(job.DependsOn) {
+			continue
+		}
+
+		var failed []string
+		for upstream, upstreamOK := range job.upstream {
+			if !upstreamOK {
+				failed = append(failed, upstream)
+			}
+		}
+		job.upstream = nil
+		switch {
+		case len(failed) > 0:
+			sort.Strings(failed)
+			s.logger.Error(fmt.Sprintf("Job %s skipped: upstream %s failed", job.Name, strings.Join(failed, ", ")))
+			s.completed(job.Name, false)
+		default:
+			s.logger.Info(fmt.Sprintf("Job %s triggered by %s", job.Name, strings.Join(job.DependsOn, ", ")))
+			s.launch(job)
+		}
+	}
+}
+
+// resetDependents сбрасывает накопленные результаты цикла у задач ниже name по графу,
+// когда запуск name пропущен или снят с очереди: иначе результаты других
+// вышестоящих задач этого цикла засчитались бы в следующем
+func (s *Scheduler) resetDependents(name string) {
+	for _, job := range s.jobs {
+		if !dependsOn(job, name) {
+			continue
+		}
+		if job.upstream != nil {
+			s.logger.Info(fmt.Sprintf("Job %s skipped in this cycle: upstream %s did not run", job.Name, name))
+			job.upstream = nil
+		}
+		s.resetDependents(job.Name)
+	}
+}
+
+func dependsOn(job *Job, name string) bool {
+	for _, d := range job.DependsOn {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Wait ждет завершения запущенных задач
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// WaitTimeout ждет завершения запущенных задач не дольше timeout или до
+// закрытия interrupt. Возвращает true, если все задачи завершились
+func (s *Scheduler) WaitTimeout(timeout time.Duration, interrupt <-chan struct{}) bool {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+	case <-interrupt:
+	}
+	return false
+}
+
+// Abort отменяет контекст выполняющихся задач. Задачи прерываются в ближайшей
+// безопасной точке; новые задачи после Abort сразу получают отмененный контекст
+func (s *Scheduler) Abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.abort()
+}
+
+// Running возвращает имена выполняющихся задач
+func (s *Scheduler) Running() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for _, job := range s.jobs {
+		if job.running && !job.queued {
+			names = append(names, job.Name)
+		}
+	}
+	return names
+}
+
+// Jobs возвращает состояние задач, отсортированных по времени следующего запуска
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]JobInfo, len(s.jobs))
+	for i, job := range s.jobs {
+		infos[i] = JobInfo{
+			Name:      job.Name,
+			Next:      job.fireAt,
+			Running:   job.running,
+			Queued:    job.queued,
+			LastRun:   job.lastRun,
+			LastErr:   job.lastErr,
+			DependsOn: job.DependsOn,
+		}
+	}
+	// Зависимые задачи без собственного времени запуска идут последними
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Next.IsZero() != infos[j].Next.IsZero() {
+			return infos[j].Next.IsZero()
+		}
+		return infos[i].Next.Before(infos[j].Next)
+	})
+	return infos
+}
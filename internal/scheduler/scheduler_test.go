@@ -0,0 +1,404 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"logger"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testLogger(t *testing.T) *logger.Log {
+	t.Helper()
+	l, err := logger.NewLogger("console", "error", "")
+	if err != nil {
+		t.Fatalf("create logger: %v", err)
+	}
+	return l
+}
+
+// startScheduler запускает планировщик и возвращает функцию, которая
+// останавливает его и ждет выхода из Start
+func startScheduler(s *Scheduler) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Start(ctx)
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func receive[T any](t *testing.T, ch <-chan T, what string) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+	var zero T
+	return zero
+}
+
+func TestRestartCancelsPreviousTerm(t *testing.T) {
+	s := New(testLogger(t))
+	started := make(chan context.Context, 2)
+	err := s.Add(&Job{
+		Name:       "sims",
+		Schedule:   Every(time.Hour),
+		RunOnStart: true,
+		Run: func(ctx context.Context) error {
+			started <- ctx
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := startScheduler(s)
+	oldTerm := receive(t, started, "first run")
+	// Потеря лидерства: планировщик остановлен, задача еще выполняется
+	stop()
+	if oldTerm.Err() != nil {
+		t.Fatal("stop cancelled the running job")
+	}
+
+	stop = startScheduler(s)
+	defer func() {
+		s.Abort()
+		stop()
+		s.Wait()
+	}()
+	select {
+	case <-oldTerm.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("job of the previous term was not cancelled by the next Start")
+	}
+}
+
+// recordRuns возвращает функцию задачи, которая сообщает о запуске в runs и
+// возвращает очередной результат из results (nil, если results пуст)
+func recordRuns(name string, runs chan<- string, results <-chan error) func(context.Context) error {
+	return func(context.Context) error {
+		runs <- name
+		select {
+		case err := <-results:
+			return err
+		default:
+			return nil
+		}
+	}
+}
+
+// drainRuns возвращает запуски, накопленные в runs
+func drainRuns(runs <-chan string) []string {
+	var names []string
+	for {
+		select {
+		case name := <-runs:
+			names = append(names, name)
+		default:
+			return names
+		}
+	}
+}
+
+func TestDependentsOfFailedUpstreamAreSkipped(t *testing.T) {
+	tests := []struct {
+		name   string
+		result error
+		want   []string
+	}{
+		{"upstream succeeded", nil, []string{"sims", "all_imsi", "report"}},
+		{"upstream failed", errors.New("load failed"), []string{"sims"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(testLogger(t))
+			runs := make(chan string, 10)
+			results := make(chan error, 1)
+			results <- tt.result
+			jobs := []*Job{
+				{Name: "sims", Schedule: Every(time.Hour), Run: recordRuns("sims", runs, results)},
+				{Name: "all_imsi", DependsOn: []string{"sims"}, Run: recordRuns("all_imsi", runs, nil)},
+				{Name: "report", DependsOn: []string{"all_imsi"}, Run: recordRuns("report", runs, nil)},
+			}
+			for _, job := range jobs {
+				if err := s.Add(job); err != nil {
+					t.Fatal(err)
+				}
+			}
+			stop := startScheduler(s)
+			defer stop()
+			waitRunning(t, s)
+
+			if err := s.Trigger("sims"); err != nil {
+				t.Fatal(err)
+			}
+			// Зависимые задачи запускаются до завершения вышестоящей, поэтому Wait ждет и их
+			s.Wait()
+			if got := drainRuns(runs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("runs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkippedUpstreamResetsDependents(t *testing.T) {
+	s := New(testLogger(t))
+	runs := make(chan string, 10)
+	msisdnResults := make(chan error, 1)
+	jobs := []*Job{
+		{Name: "sims", Schedule: Every(time.Hour), Run: recordRuns("sims", runs, nil)},
+		{Name: "msisdn", Schedule: Every(time.Hour), Run: recordRuns("msisdn", runs, msisdnResults)},
+		{Name: "report", DependsOn: []string{"sims", "msisdn"}, Run: recordRuns("report", runs, nil)},
+	}
+	for _, job := range jobs {
+		if err := s.Add(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stop := startScheduler(s)
+	defer stop()
+	waitRunning(t, s)
+
+	trigger := func(name string) {
+		t.Helper()
+		if err := s.Trigger(name); err != nil {
+			t.Fatal(err)
+		}
+		s.Wait()
+	}
+
+	// Первый цикл: sims загружена, msisdn пропущена - результат sims сбрасывается
+	trigger("sims")
+	msisdnResults <- fmt.Errorf("table is loaded by another instance: %w", ErrSkipped)
+	trigger("msisdn")
+	// Второй цикл: одной msisdn недостаточно, sims прошлого цикла не засчитывается
+	trigger("msisdn")
+	if got, want := drainRuns(runs), []string{"sims", "msisdn", "msisdn"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("runs = %v, want %v", got, want)
+	}
+	for _, info := range s.Jobs() {
+		if info.Name == "msisdn" && info.LastErr != nil {
+			t.Errorf("skipped run recorded as failure: %v", info.LastErr)
+		}
+	}
+
+	trigger("sims")
+	if got, want := drainRuns(runs), []string{"sims", "report"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("runs = %v, want %v", got, want)
+	}
+}
+
+// waitRunning ждет, пока Start снимет признак остановки и примет ручные запуски
+func waitRunning(t *testing.T, s *Scheduler) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		running := !s.stopping
+		s.mu.Unlock()
+		if running {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("scheduler did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestResourceLimit(t *testing.T) {
+	const limit = 2
+	s := New(testLogger(t), WithResourceLimit("oracle/main", limit))
+	started := make(chan string, limit+1)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	running, peak := 0, 0
+	for i := 0; i <= limit; i++ {
+		name := fmt.Sprintf("table%d", i)
+		err := s.Add(&Job{
+			Name:      name,
+			Schedule:  Every(time.Hour),
+			Resources: []string{"oracle/main", "mariadb/" + name},
+			Run: func(context.Context) error {
+				mu.Lock()
+				running++
+				peak = max(peak, running)
+				mu.Unlock()
+				started <- name
+				<-release
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	stop := startScheduler(s)
+	defer stop()
+	waitRunning(t, s)
+
+	for i := 0; i <= limit; i++ {
+		if err := s.Trigger(fmt.Sprintf("table%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first := map[string]bool{}
+	for i := 0; i < limit; i++ {
+		first[receive(t, started, "run within the limit")] = true
+	}
+	select {
+	case name := <-started:
+		t.Fatalf("job %s started over the limit", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if depth := s.QueueDepth(); depth != 1 {
+		t.Errorf("queue depth = %d, want 1", depth)
+	}
+
+	// Освободившееся место занимает задача из очереди
+	release <- struct{}{}
+	queued := receive(t, started, "queued job")
+	if first[queued] {
+		t.Errorf("job %s ran twice", queued)
+	}
+	if depth := s.QueueDepth(); depth != 0 {
+		t.Errorf("queue depth = %d after a slot freed, want 0", depth)
+	}
+	close(release)
+	s.Wait()
+
+	if peak > limit {
+		t.Errorf("%d jobs ran at once, limit %d", peak, limit)
+	}
+}
+
+// blockingJob - задача с общей целевой таблицей, которая выполняется до сигнала в release
+func blockingJob(name, lockKey, overlap string, started chan<- string, release <-chan error) *Job {
+	return &Job{
+		Name:     name,
+		Schedule: Every(time.Hour),
+		LockKey:  lockKey,
+		Overlap:  overlap,
+		Run: func(context.Context) error {
+			started <- name
+			return <-release
+		},
+	}
+}
+
+func TestOverlapPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		overlap string
+		wantErr error // Ответ Trigger второй задачи
+		want    []string
+	}{
+		{"skip", OverlapSkip, ErrJobBusy, []string{"oracle_sims"}},
+		{"default is skip", "", ErrJobBusy, []string{"oracle_sims"}},
+		// Отложенные запуски выполняются по одному после освобождения таблицы
+		{"queue", OverlapQueue, nil, []string{"oracle_sims", "oracle_sims", "mariadb_sims"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(testLogger(t))
+			started := make(chan string, 4)
+			release := make(chan error, 4)
+			// Две секции sync загружают одну целевую таблицу
+			for _, job := range []*Job{
+				blockingJob("oracle_sims", "sims", tt.overlap, started, release),
+				blockingJob("mariadb_sims", "sims", tt.overlap, started, release),
+			} {
+				if err := s.Add(job); err != nil {
+					t.Fatal(err)
+				}
+			}
+			stop := startScheduler(s)
+			defer stop()
+			waitRunning(t, s)
+
+			if err := s.Trigger("oracle_sims"); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{receive(t, started, "first run")}
+			if err := s.Trigger("mariadb_sims"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Trigger while the table is loading = %v, want %v", err, tt.wantErr)
+			}
+			// Повторный запуск той же задачи подчиняется той же политике
+			if err := s.Trigger("oracle_sims"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Trigger of the running job = %v, want %v", err, tt.wantErr)
+			}
+			select {
+			case name := <-started:
+				t.Fatalf("job %s started while the table was loading", name)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			release <- nil
+			for len(got) < len(tt.want) {
+				got = append(got, receive(t, started, "deferred run"))
+				release <- nil
+			}
+			s.Wait()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("runs = %v, want %v", got, tt.want)
+			}
+			if extra := drainRuns(started); len(extra) > 0 {
+				t.Errorf("extra runs %v", extra)
+			}
+		})
+	}
+}
+
+func TestLockKeyReleasedAfterFailure(t *testing.T) {
+	s := New(testLogger(t))
+	started := make(chan string, 2)
+	release := make(chan error, 2)
+	for _, job := range []*Job{
+		blockingJob("oracle_sims", "sims", OverlapSkip, started, release),
+		blockingJob("mariadb_sims", "sims", OverlapSkip, started, release),
+	} {
+		if err := s.Add(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stop := startScheduler(s)
+	defer stop()
+	waitRunning(t, s)
+
+	if err := s.Trigger("oracle_sims"); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, started, "first run")
+	release <- errors.New("release lock sims: connection reset")
+	s.Wait()
+
+	s.mu.Lock()
+	holder := s.locks["sims"]
+	s.mu.Unlock()
+	if holder != nil {
+		t.Fatalf("lock sims still held by %s after the job failed", holder.Name)
+	}
+	if err := s.Trigger("mariadb_sims"); err != nil {
+		t.Fatalf("Trigger after the failed run: %v", err)
+	}
+	if name := receive(t, started, "run after failure"); name != "mariadb_sims" {
+		t.Errorf("started %s, want mariadb_sims", name)
+	}
+	release <- nil
+	s.Wait()
+}
@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window - ежедневный интервал времени, в котором запуски запрещены (blackout)
+type Window struct {
+	start, end time.Duration // Смещение от полуночи
+	days       uint8         // Битовая маска дней недели (0 - все дни)
+	loc        *time.Location
+}
+
+// ParseWindow разбирает окно вида "08:00"-"20:00" с необязательным списком дней
+// ("mon", "tue", ...). Если end меньше start, окно переходит через полночь
+func ParseWindow(start, end string, days []string, loc *time.Location) (Window, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	w := Window{loc: loc}
+	var err error
+	if w.start, err = parseClock(start); err != nil {
+		return w, fmt.Errorf("invalid blackout start: %w", err)
+	}
+	if w.end, err = parseClock(end); err != nil {
+		return w, fmt.Errorf("invalid blackout end: %w", err)
+	}
+	if w.start == w.end {
+		return w, fmt.Errorf("blackout start and end must differ")
+	}
+	for _, d := range days {
+		n, ok := dayNames[strings.ToLower(d)]
+		if !ok {
+			return w, fmt.Errorf("invalid blackout day %q", d)
+		}
+		w.days |= 1 << uint(n)
+	}
+	return w, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// End возвращает конец окна, если t попадает в окно
+func (w Window) End(t time.Time) (time.Time, bool) {
+	t = t.In(w.loc)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, w.loc)
+	offset := t.Sub(midnight)
+
+	if w.start < w.end {
+		if offset >= w.start && offset < w.end && w.dayAllowed(midnight) {
+			return midnight.Add(w.end), true
+		}
+		return time.Time{}, false
+	}
+	// Окно через полночь: день окна - день его начала
+	if offset >= w.start && w.dayAllowed(midnight) {
+		return midnight.AddDate(0, 0, 1).Add(w.end), true
+	}
+	if offset < w.end {
+		prev := midnight.AddDate(0, 0, -1)
+		if w.dayAllowed(prev) {
+			return midnight.Add(w.end), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (w Window) dayAllowed(day time.Time) bool {
+	return w.days == 0 || w.days&(1<<uint(day.Weekday())) != 0
+}
+
+// blackoutSchedule дополняет расписание окнами запрета
+type blackoutSchedule struct {
+	base      Schedule
+	blackouts []Window
+}
+
+// Next возвращает следующий запуск вне окон запрета. Запуски, попавшие в окно,
+// переносятся на ближайшее время расписания после окончания окна
+func (s blackoutSchedule) Next(t time.Time) time.Time {
+	next := s.base.Next(t)
+	for i := 0; i < 1000 && !next.IsZero(); i++ {
+		end, blocked := s.blockedUntil(next)
+		if !blocked {
+			break
+		}
+		if _, interval := s.base.(intervalSchedule); interval {
+			next = end
+			continue
+		}
+		// Следующее время cron строго после конца окна; сам конец окна тоже допустим
+		next = s.base.Next(end.Add(-time.Minute))
+	}
+	return next
+}
+
+func (s blackoutSchedule) blockedUntil(t time.Time) (time.Time, bool) {
+	for _, w := range s.blackouts {
+		if end, ok := w.End(t); ok {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// WithBlackouts добавляет к расписанию окна запрета
+func WithBlackouts(base Schedule, blackouts []Window) Schedule {
+	if len(blackouts) == 0 {
+		return base
+	}
+	return blackoutSchedule{base: base, blackouts: blackouts}
+}
@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindowErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		days       []string
+	}{
+		{"bad start", "8:00pm", "20:00", nil},
+		{"bad end", "08:00", "25:00", nil},
+		{"empty window", "08:00", "08:00", nil},
+		{"bad day", "08:00", "20:00", []string{"monday"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseWindow(tt.start, tt.end, tt.days, time.UTC); err == nil {
+				t.Errorf("ParseWindow(%q, %q, %v) succeeded", tt.start, tt.end, tt.days)
+			}
+		})
+	}
+}
+
+func TestWindowEnd(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatalf("parse %q: %v", s, err)
+		}
+		return v
+	}
+	// 2025-01-03 - пятница, 2025-01-04 - суббота
+	tests := []struct {
+		name       string
+		start, end string
+		days       []string
+		t          string
+		want       string // Пусто - t вне окна
+	}{
+		{"inside", "12:00", "13:00", nil, "2025-01-03 12:30", "2025-01-03 13:00"},
+		{"at start", "12:00", "13:00", nil, "2025-01-03 12:00", "2025-01-03 13:00"},
+		{"at end", "12:00", "13:00", nil, "2025-01-03 13:00", ""},
+		{"before", "12:00", "13:00", nil, "2025-01-03 11:59", ""},
+		{"day allowed", "12:00", "13:00", []string{"fri"}, "2025-01-03 12:30", "2025-01-03 13:00"},
+		{"day not allowed", "12:00", "13:00", []string{"mon"}, "2025-01-03 12:30", ""},
+		{"overnight evening", "22:00", "06:00", nil, "2025-01-03 23:00", "2025-01-04 06:00"},
+		{"overnight morning", "22:00", "06:00", nil, "2025-01-04 05:00", "2025-01-04 06:00"},
+		{"overnight daytime", "22:00", "06:00", nil, "2025-01-04 12:00", ""},
+		{"overnight morning of window day", "22:00", "06:00", []string{"fri"}, "2025-01-04 05:00", "2025-01-04 06:00"},
+		{"overnight morning of next day", "22:00", "06:00", []string{"fri"}, "2025-01-03 05:00", ""},
+		{"overnight evening of other day", "22:00", "06:00", []string{"fri"}, "2025-01-04 23:00", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := ParseWindow(tt.start, tt.end, tt.days, time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			end, ok := w.End(at(tt.t))
+			if tt.want == "" {
+				if ok {
+					t.Errorf("End(%s) = %s, want outside window", tt.t, end)
+				}
+				return
+			}
+			if !ok || !end.Equal(at(tt.want)) {
+				t.Errorf("End(%s) = %s, %t, want %s", tt.t, end, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithBlackouts(t *testing.T) {
+	window := func(start, end string, days ...string) Window {
+		w, err := ParseWindow(start, end, days, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+	cron := func(expr string) Schedule {
+		s, err := ParseCron(expr, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	day := func(d, hour, min int) time.Time {
+		return time.Date(2025, 1, d, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		base      Schedule
+		blackouts []Window
+		from      time.Time
+		want      time.Time
+	}{
+		{"outside window", cron("0 * * * *"), []Window{window("12:00", "13:00")}, day(3, 10, 30), day(3, 11, 0)},
+		{"cron moved after window", cron("*/15 * * * *"), []Window{window("12:00", "13:00")}, day(3, 11, 50), day(3, 13, 0)},
+		{"cron to next match after window", cron("10 * * * *"), []Window{window("12:00", "13:00")}, day(3, 11, 50), day(3, 13, 10)},
+		{"interval moved to window end", Every(30 * time.Minute), []Window{window("12:00", "13:00")}, day(3, 11, 45), day(3, 13, 0)},
+		{"overnight window", cron("0 * * * *"), []Window{window("22:00", "06:00")}, day(3, 21, 30), day(4, 6, 0)},
+		{"adjacent windows", cron("0 * * * *"), []Window{window("12:00", "13:00"), window("13:00", "15:00")}, day(3, 11, 30), day(3, 15, 0)},
+		{"window on other day", cron("0 * * * *"), []Window{window("12:00", "13:00", "mon")}, day(3, 11, 30), day(3, 12, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := WithBlackouts(tt.base, tt.blackouts)
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+
+	base := cron("0 * * * *")
+	if s := WithBlackouts(base, nil); s != base {
+		t.Error("WithBlackouts without windows changed the schedule")
+	}
+}
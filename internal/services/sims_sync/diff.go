@@ -0,0 +1,338 @@
+package sims_sync
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/domain"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Виды расхождений строк
+const (
+	DiffMissing = "missing" // Строка есть в источнике, но нет в цели
+	DiffExtra   = "extra"   // Строка есть в цели, но нет в источнике
+	DiffChanged = "changed" // Строка есть в обеих таблицах, значения колонок различаются
+)
+
+// DiffOptions - параметры сравнения таблиц
+type DiffOptions struct {
+	Columns    []string // Колонки цели для сравнения (пусто - все колонки цели)
+	KeyColumn  string   // Числовая колонка цели для разбиения на диапазоны ("" - таблица целиком)
+	RangeSize  int64    // Размер диапазона ключей
+	Mode       string   // auto, pushdown, stream
+	MaxDetails int      // Максимальное количество строк в детальном отчете (0 - без ограничения)
+}
+
+// DiffRow - расхождение одной строки
+type DiffRow struct {
+	Kind    string                 `json:"kind"`
+	Key     string                 `json:"key"`
+	Columns []string               `json:"columns,omitempty"` // Различающиеся колонки (для changed)
+	Source  map[string]interface{} `json:"source,omitempty"`
+	Target  map[string]interface{} `json:"target,omitempty"`
+}
+
+// DiffReport - результат сравнения таблиц
+type DiffReport struct {
+	SourceTable   string    `json:"source_table"`
+	TargetTable   string    `json:"target_table"`
+	RangesChecked int       `json:"ranges_checked"`
+	RangesDiffer  int       `json:"ranges_differ"`
+	Missing       int       `json:"missing"`
+	Extra         int       `json:"extra"`
+	Changed       int       `json:"changed"`
+	Rows          []DiffRow `json:"rows,omitempty"`
+}
+
+// Equal сообщает, что таблицы совпадают
+func (r *DiffReport) Equal() bool {
+	return r.Missing == 0 && r.Extra == 0 && r.Changed == 0
+}
+
+// Summary возвращает краткий итог сравнения
+func (r *DiffReport) Summary() string {
+	return fmt.Sprintf("%s -> %s: %d of %d key ranges differ, missing %d, extra %d, changed %d",
+		r.SourceTable, r.TargetTable, r.RangesDiffer, r.RangesChecked, r.Missing, r.Extra, r.Changed)
+}
+
+// WriteJSON записывает отчет в формате JSON
+func (r *DiffReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV записывает расхождения строк в формате CSV
+func (r *DiffReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"kind", "key", "column", "source_value", "target_value"}); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		columns := row.Columns
+		if len(columns) == 0 {
+			columns = []string{""}
+		}
+		for _, c := range columns {
+			var src, tgt string
+			if c != "" {
+				src, tgt = csvValue(row.Source, c), csvValue(row.Target, c)
+			}
+			if err := cw.Write([]string{row.Kind, row.Key, c, src, tgt}); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvValue(values map[string]interface{}, column string) string {
+	if values == nil {
+		return ""
+	}
+	return canonicalValue(values[column])
+}
+
+// diffColumn - пара колонок источника и цели
+type diffColumn struct {
+	source string
+	target string
+}
+
+// Diff сравнивает таблицу источника с таблицей цели по первичному ключу цели.
+// Сначала по диапазонам ключей считаются контрольные суммы, затем строки
+// читаются только из диапазонов, где суммы не совпали
+func Diff(ctx context.Context, source, target connectors.DatabaseConnector, cfg config.SyncConfig, opts DiffOptions) (*DiffReport, error) {
+	sourceFrom := cfg.Source.Table
+	if sourceFrom == "" {
+		if cfg.Source.Query == "" {
+			return nil, fmt.Errorf("source table or query is required")
+		}
+		sourceFrom = fmt.Sprintf("(%s) src", cfg.Source.Query)
+	}
+	if cfg.Target.Table == "" {
+		return nil, fmt.Errorf("target table is required")
+	}
+
+	// Хеш-функции диалектов различаются, и явный pushdown не подменяется чтением строк
+	if opts.Mode == VerifyModePushdown && connectors.Dialect(source) != connectors.Dialect(target) {
+		return nil, fmt.Errorf("mode pushdown requires source and target of the same type")
+	}
+
+	columns, keys, err := diffColumns(source, target, cfg, sourceFrom, opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	v := newVerifier(config.VerifyConfig{RangeSize: opts.RangeSize, Mode: opts.Mode})
+	sourceSide := checksumSide{conn: source, table: sourceFrom}
+	targetSide := checksumSide{conn: target, table: cfg.Target.Table}
+	for _, c := range columns {
+		sourceSide.columns = append(sourceSide.columns, c.source)
+		targetSide.columns = append(targetSide.columns, c.target)
+	}
+	if opts.KeyColumn != "" {
+		keyCol, ok := findDiffColumn(columns, opts.KeyColumn)
+		if !ok {
+			return nil, fmt.Errorf("key column %s is not compared", opts.KeyColumn)
+		}
+		sourceSide.key, targetSide.key = keyCol.source, keyCol.target
+	}
+
+	ranges, total, err := v.compareRanges(ctx, sourceSide, targetSide)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DiffReport{
+		SourceTable:   sourceFrom,
+		TargetTable:   cfg.Target.Table,
+		RangesChecked: total,
+		RangesDiffer:  len(ranges),
+	}
+
+	for _, r := range ranges {
+		sourceRows, err := fetchRange(ctx, sourceSide, r.bucket, v.rangeSize)
+		if err != nil {
+			return nil, fmt.Errorf("read source range failed: %w", err)
+		}
+		targetRows, err := fetchRange(ctx, targetSide, r.bucket, v.rangeSize)
+		if err != nil {
+			return nil, fmt.Errorf("read target range failed: %w", err)
+		}
+		compareRows(report, columns, keys, sourceRows, targetRows, opts.MaxDetails)
+	}
+	return report, nil
+}
+
+// diffColumns определяет пары колонок и колонки первичного ключа
+func diffColumns(source, target connectors.DatabaseConnector, cfg config.SyncConfig, sourceFrom string, only []string) ([]diffColumn, []diffColumn, error) {
+	sourceSchema, err := source.ExecuteSelectWithSchema(fmt.Sprintf("SELECT * FROM %s WHERE 1=0", sourceFrom))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get source schema: %w", err)
+	}
+
+	targetSchema := &domain.TableSchema{PrimaryKey: cfg.Target.PrimaryKey}
+	for _, col := range cfg.Target.Columns {
+		targetSchema.Columns = append(targetSchema.Columns, domain.ColumnInfo{Name: col.Name, DataType: col.DataType})
+	}
+	if len(targetSchema.Columns) == 0 {
+		targetSchema, err = target.ExecuteSelectWithSchema(fmt.Sprintf("SELECT * FROM %s WHERE 1=0", cfg.Target.Table))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get target schema: %w", err)
+		}
+		if cfg.Target.PrimaryKey != "" {
+			targetSchema.PrimaryKey = cfg.Target.PrimaryKey
+		}
+	}
+	if targetSchema.PrimaryKey == "" {
+		return nil, nil, fmt.Errorf("target primaryKey is required to match rows")
+	}
+
+	// Сопоставление колонок то же, что и при синхронизации
+	processor := NewDataProcessor(0, WithSchemas(sourceSchema, targetSchema))
+	pair := func(name string) diffColumn {
+		return diffColumn{source: processor.SourceColumnFor(name), target: name}
+	}
+
+	var columns []diffColumn
+	if len(only) > 0 {
+		for _, c := range only {
+			columns = append(columns, pair(c))
+		}
+	} else {
+		// Колонки цели без пары в источнике (например, автоинкремент) не сравниваются
+		for _, c := range targetSchema.Columns {
+			if col := pair(c.Name); containsFold(sourceSchema.Columns, col.source) {
+				columns = append(columns, col)
+			}
+		}
+	}
+
+	var keys []diffColumn
+	for _, k := range strings.Split(targetSchema.PrimaryKey, ",") {
+		if k = strings.TrimSpace(k); k == "" {
+			continue
+		}
+		keyCol, ok := findDiffColumn(columns, k)
+		if !ok {
+			keyCol = pair(k)
+			columns = append(columns, keyCol)
+		}
+		keys = append(keys, keyCol)
+	}
+	return columns, keys, nil
+}
+
+func findDiffColumn(columns []diffColumn, target string) (diffColumn, bool) {
+	for _, c := range columns {
+		if strings.EqualFold(c.target, target) {
+			return c, true
+		}
+	}
+	return diffColumn{}, false
+}
+
+// fetchRange читает строки одного диапазона ключей
+func fetchRange(ctx context.Context, side checksumSide, bucket string, rangeSize int64) ([]domain.Record, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(side.columns, ", "), side.table)
+	if side.key != "" {
+		if n, ok := new(big.Int).SetString(bucket, 10); ok {
+			from := new(big.Int).Mul(n, big.NewInt(rangeSize))
+			to := new(big.Int).Add(from, big.NewInt(rangeSize))
+			query += fmt.Sprintf(" WHERE %s >= %s AND %s < %s", side.key, from, side.key, to)
+		} else {
+			query += fmt.Sprintf(" WHERE %s IS NULL", side.key)
+		}
+	}
+	return side.conn.ExecuteSelect(ctx, query)
+}
+
+// compareRows сопоставляет строки диапазона по ключу и добавляет расхождения в отчет
+func compareRows(report *DiffReport, columns, keys []diffColumn, sourceRows, targetRows []domain.Record, maxDetails int) {
+	keyOf := func(record domain.Record, source bool) string {
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			name := k.target
+			if source {
+				name = k.source
+			}
+			parts[i] = fmt.Sprintf("%s=%s", k.target, canonicalValue(fieldValue(record, name)))
+		}
+		return strings.Join(parts, ",")
+	}
+	values := func(record domain.Record, source bool) map[string]interface{} {
+		out := make(map[string]interface{}, len(columns))
+		for _, c := range columns {
+			name := c.target
+			if source {
+				name = c.source
+			}
+			out[c.target] = fieldValue(record, name)
+		}
+		return out
+	}
+	add := func(row DiffRow) {
+		if maxDetails <= 0 || len(report.Rows) < maxDetails {
+			report.Rows = append(report.Rows, row)
+		}
+	}
+
+	targetByKey := make(map[string]domain.Record, len(targetRows))
+	for _, r := range targetRows {
+		targetByKey[keyOf(r, false)] = r
+	}
+
+	seen := make(map[string]bool, len(sourceRows))
+	var sourceKeys []string
+	sourceByKey := make(map[string]domain.Record, len(sourceRows))
+	for _, r := range sourceRows {
+		k := keyOf(r, true)
+		if !seen[k] {
+			seen[k] = true
+			sourceKeys = append(sourceKeys, k)
+		}
+		sourceByKey[k] = r
+	}
+	sort.Strings(sourceKeys)
+
+	for _, k := range sourceKeys {
+		src := sourceByKey[k]
+		tgt, ok := targetByKey[k]
+		if !ok {
+			report.Missing++
+			add(DiffRow{Kind: DiffMissing, Key: k, Source: values(src, true)})
+			continue
+		}
+		var changed []string
+		for _, c := range columns {
+			if canonicalValue(fieldValue(src, c.source)) != canonicalValue(fieldValue(tgt, c.target)) {
+				changed = append(changed, c.target)
+			}
+		}
+		if len(changed) > 0 {
+			report.Changed++
+			add(DiffRow{Kind: DiffChanged, Key: k, Columns: changed, Source: values(src, true), Target: values(tgt, false)})
+		}
+	}
+
+	var extraKeys []string
+	for k := range targetByKey {
+		if !seen[k] {
+			extraKeys = append(extraKeys, k)
+		}
+	}
+	sort.Strings(extraKeys)
+	for _, k := range extraKeys {
+		report.Extra++
+		add(DiffRow{Kind: DiffExtra, Key: k, Target: values(targetByKey[k], false)})
+	}
+}
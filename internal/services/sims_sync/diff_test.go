@@ -0,0 +1,72 @@
+package sims_sync
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/domain"
+	"reflect"
+	"testing"
+)
+
+func TestCompareRows(t *testing.T) {
+	columns := []diffColumn{
+		{source: "ID", target: "id"},
+		{source: "IMSI", target: "imsi"},
+		{source: "STATUS", target: "status"},
+	}
+	keys := columns[:1]
+	sourceRows := []domain.Record{
+		{"ID": int64(1), "IMSI": "250010000000001", "STATUS": "active"},
+		{"ID": int64(2), "IMSI": "00123", "STATUS": "active"},
+		{"ID": int64(3), "IMSI": "250010000000003", "STATUS": "blocked"},
+	}
+	targetRows := []domain.Record{
+		{"id": "1", "imsi": "250010000000001", "status": "active"},
+		{"id": "2", "imsi": "123", "status": "active"},
+		{"id": "4", "imsi": "250010000000004", "status": "active"},
+	}
+
+	report := &DiffReport{}
+	compareRows(report, columns, keys, sourceRows, targetRows, 0)
+
+	if report.Missing != 1 || report.Extra != 1 || report.Changed != 1 {
+		t.Fatalf("missing/extra/changed = %d/%d/%d, want 1/1/1", report.Missing, report.Extra, report.Changed)
+	}
+	want := map[string]DiffRow{
+		"id=2": {Kind: DiffChanged, Columns: []string{"imsi"}},
+		"id=3": {Kind: DiffMissing},
+		"id=4": {Kind: DiffExtra},
+	}
+	for _, row := range report.Rows {
+		w, ok := want[row.Key]
+		if !ok {
+			t.Errorf("unexpected row %+v", row)
+			continue
+		}
+		if row.Kind != w.Kind || !reflect.DeepEqual(row.Columns, w.Columns) {
+			t.Errorf("row %s = %s %v, want %s %v", row.Key, row.Kind, row.Columns, w.Kind, w.Columns)
+		}
+		delete(want, row.Key)
+	}
+	for key := range want {
+		t.Errorf("row %s not reported", key)
+	}
+
+	limited := &DiffReport{}
+	compareRows(limited, columns, keys, sourceRows, targetRows, 1)
+	if len(limited.Rows) != 1 || limited.Missing+limited.Extra+limited.Changed != 3 {
+		t.Errorf("maxDetails 1: %d rows, %d differences", len(limited.Rows), limited.Missing+limited.Extra+limited.Changed)
+	}
+}
+
+func TestDiffRejectsCrossDialectPushdown(t *testing.T) {
+	cfg := config.SyncConfig{}
+	cfg.Source.Table = "SIMS"
+	cfg.Target.Table = "sims"
+
+	_, err := Diff(context.Background(), &connectors.OracleConnector{}, &fakeConnector{}, cfg, DiffOptions{Mode: VerifyModePushdown})
+	if err == nil {
+		t.Fatal("Diff with pushdown across dialects succeeded")
+	}
+}
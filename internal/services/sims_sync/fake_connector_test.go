@@ -0,0 +1,90 @@
+package sims_sync
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/domain"
+	"logger"
+	"strings"
+	"testing"
+)
+
+// fakeConnector - коннектор для тестов. Методы, которые тест не задал, паникуют
+// через встроенный nil-интерфейс
+type fakeConnector struct {
+	connectors.DatabaseConnector
+
+	// selectFn отвечает на ExecuteSelect
+	selectFn func(query string, args []interface{}) ([]domain.Record, error)
+	// insertFn проверяет запись перед вставкой; ошибка отклоняет всю пачку
+	insertFn func(record domain.Record) error
+
+	// tables отвечает на ListTables
+	tables []string
+
+	queries  []string
+	inserted []domain.Record
+	created  []string // Созданные промежуточные таблицы
+	dropped  []string
+	swapped  []string // Опубликованные заменой промежуточные таблицы
+}
+
+func (f *fakeConnector) ExecuteSelect(_ context.Context, query string, args ...interface{}) ([]domain.Record, error) {
+	f.queries = append(f.queries, query)
+	if f.selectFn == nil {
+		return nil, nil
+	}
+	return f.selectFn(query, args)
+}
+
+func (f *fakeConnector) InsertBatch(_ context.Context, _ string, records []domain.Record, _ []string) error {
+	if f.insertFn != nil {
+		for _, r := range records {
+			if err := f.insertFn(r); err != nil {
+				return err
+			}
+		}
+	}
+	f.inserted = append(f.inserted, records...)
+	return nil
+}
+
+func (f *fakeConnector) CreateTableIfNotExists(string, *domain.TableSchema) error {
+	return nil
+}
+
+func (f *fakeConnector) CreateTempTable(_, tempTable string, _ *domain.TableSchema) error {
+	f.created = append(f.created, tempTable)
+	return nil
+}
+
+func (f *fakeConnector) DropTable(table string) error {
+	f.dropped = append(f.dropped, table)
+	return nil
+}
+
+func (f *fakeConnector) SwapTables(_, tempTable string) error {
+	f.swapped = append(f.swapped, tempTable)
+	return nil
+}
+
+func (f *fakeConnector) ListTables(prefix string) ([]string, error) {
+	var tables []string
+	for _, t := range f.tables {
+		if len(t) >= len(prefix) && strings.EqualFold(t[:len(prefix)], prefix) {
+			tables = append(tables, t)
+		}
+	}
+	return tables, nil
+}
+
+// newTestService собирает сервис синхронизации без обращения к БД при создании
+func newTestService(t *testing.T, source, target connectors.DatabaseConnector, processor *DataProcessor, cfg config.SyncConfig) *SyncService {
+	t.Helper()
+	l, err := logger.NewLogger("console", "error", "")
+	if err != nil {
+		t.Fatalf("create logger: %v", err)
+	}
+	return &SyncService{source: source, target: target, processor: processor, config: cfg, logger: l}
+}
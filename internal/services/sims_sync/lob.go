@@ -0,0 +1,28 @@
+package sims_sync
+
+import (
+	"db_swapper/internal/config"
+	"db_swapper/internal/domain"
+	"strings"
+)
+
+// withLOBLimits проставляет ограничения размера LOB колонкам схемы источника
+func withLOBLimits(schema *domain.TableSchema, limits []config.LOBLimitConfig) {
+	if schema == nil || len(limits) == 0 {
+		return
+	}
+	for _, l := range limits {
+		onExceed := l.OnExceed
+		if onExceed == "" {
+			onExceed = domain.LOBFail
+		}
+		for i := range schema.Columns {
+			if strings.EqualFold(schema.Columns[i].Name, l.Column) {
+				schema.Columns[i].LOBLimit = &domain.LOBLimit{
+					MaxSize:  l.MaxSize,
+					OnExceed: onExceed,
+				}
+			}
+		}
+	}
+}
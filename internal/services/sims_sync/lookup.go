@@ -0,0 +1,168 @@
+package sims_sync
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/domain"
+	"fmt"
+	"strings"
+)
+
+// Поведение при отсутствии ключа в справочнике
+const (
+	LookupMissNull    = "null"    // Записать NULL
+	LookupMissDefault = "default" // Записать значение по умолчанию
+	LookupMissReject  = "reject"  // Отбросить запись
+)
+
+// Lookup обогащает записи значением из справочника другой БД
+// (например, название отдела по коду)
+type Lookup struct {
+	conn   connectors.DatabaseConnector
+	cfg    config.LookupConfig
+	values map[string]interface{} // Полный справочник (cache_size = 0)
+	cache  *lruCache              // Ограниченный кеш с дозагрузкой по ключу (cache_size > 0)
+	loaded bool
+}
+
+// NewLookup создает шаг обогащения. Справочник загружается при первом прогоне
+func NewLookup(conn connectors.DatabaseConnector, cfg config.LookupConfig) (*Lookup, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("lookup %s: connection %s not found", cfg.Name, cfg.Connection)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.OnMiss == "" {
+		cfg.OnMiss = LookupMissNull
+	}
+	return &Lookup{conn: conn, cfg: cfg}, nil
+}
+
+// WithLookup добавляет шаг обогащения записей
+func WithLookup(l *Lookup) ProcessorOption {
+	return func(p *DataProcessor) {
+		p.lookups = append(p.lookups, l)
+	}
+}
+
+// baseQuery возвращает запрос, выбирающий ключ и значение справочника
+func (l *Lookup) baseQuery() string {
+	if l.cfg.Query != "" {
+		return l.cfg.Query
+	}
+	return fmt.Sprintf("SELECT %s, %s FROM %s", l.cfg.KeyColumn, l.cfg.ValueColumn, l.cfg.Table)
+}
+
+// Load загружает справочник заново. Вызывается в начале каждого прогона,
+// если refresh не равен "never"
+func (l *Lookup) Load(ctx context.Context) error {
+	if l.loaded && l.cfg.Refresh == config.LookupRefreshNever {
+		return nil
+	}
+
+	// В режиме LRU справочник не читается целиком, кеш наполняется по мере обращений
+	if l.cfg.CacheSize > 0 {
+		l.cache = newLRUCache(l.cfg.CacheSize)
+		l.loaded = true
+		return nil
+	}
+
+	rows, err := l.conn.ExecuteSelect(ctx, l.baseQuery())
+	if err != nil {
+		return fmt.Errorf("lookup %s: load failed: %w", l.cfg.Name, err)
+	}
+	values := make(map[string]interface{}, len(rows))
+	for _, row := range rows {
+		key := fieldValue(row, l.cfg.KeyColumn)
+		if key == nil {
+			continue
+		}
+		values[valueString(key)] = fieldValue(row, l.cfg.ValueColumn)
+	}
+	l.values = values
+	l.loaded = true
+	return nil
+}
+
+// resolve ищет значение по ключу
+func (l *Lookup) resolve(ctx context.Context, key interface{}) (interface{}, bool, error) {
+	k := valueString(key)
+	if l.cache == nil {
+		v, ok := l.values[k]
+		return v, ok, nil
+	}
+
+	if v, ok := l.cache.Get(k); ok {
+		if _, miss := v.(lookupMiss); miss {
+			return nil, false, nil
+		}
+		return v, true, nil
+	}
+	query := fmt.Sprintf("SELECT %s, %s FROM (%s) q WHERE %s = %s",
+		l.cfg.KeyColumn, l.cfg.ValueColumn, l.baseQuery(), l.cfg.KeyColumn, connectors.Placeholder(l.conn, 1))
+	rows, err := l.conn.ExecuteSelect(ctx, query, k)
+	if err != nil {
+		return nil, false, fmt.Errorf("lookup %s: query failed: %w", l.cfg.Name, err)
+	}
+	if len(rows) == 0 {
+		// Кешируем и отсутствие ключа, чтобы не повторять запрос
+		l.cache.Put(k, lookupMiss{})
+		return nil, false, nil
+	}
+	v := fieldValue(rows[0], l.cfg.ValueColumn)
+	l.cache.Put(k, v)
+	return v, true, nil
+}
+
+// lookupMiss - отметка об отсутствии ключа в кеше LRU
+type lookupMiss struct{}
+
+// enrich дополняет запись. Возвращает false, если запись нужно отбросить
+func (l *Lookup) enrich(ctx context.Context, record domain.Record) (bool, error) {
+	target := l.cfg.TargetColumn
+	if target == "" {
+		target = l.cfg.ValueColumn
+	}
+
+	key := fieldValue(record, l.cfg.SourceColumn)
+	var (
+		value interface{}
+		found bool
+		err   error
+	)
+	if key != nil {
+		value, found, err = l.resolve(ctx, key)
+		if err != nil {
+			return false, err
+		}
+	}
+	if found {
+		record[target] = value
+		return true, nil
+	}
+
+	switch l.cfg.OnMiss {
+	case LookupMissDefault:
+		record[target] = l.cfg.Default
+	case LookupMissReject:
+		return false, nil
+	default:
+		record[target] = nil
+	}
+	return true, nil
+}
+
+// fieldValue возвращает значение колонки без учета регистра имени
+func fieldValue(record domain.Record, name string) interface{} {
+	if v, ok := record[name]; ok {
+		return v
+	}
+	for k, v := range record {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package sims_sync
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"db_swapper/internal/config"
+	"db_swapper/internal/domain"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Методы маскирования
+const (
+	MaskHMAC             = "hmac"              // Детерминированный хеш с ключом (сохраняет связность по JOIN)
+	MaskFormatPreserving = "format_preserving" // Замена цифр с сохранением длины и формата (MSISDN, IMSI, ICCID)
+	MaskRedact           = "redact"            // Частичное скрытие символов
+	MaskNull             = "null"              // Замена на NULL
+)
+
+// maskRule - подготовленное правило маскирования колонки
+type maskRule struct {
+	method     string
+	keepPrefix int
+	keepSuffix int
+	maskChar   rune
+	length     int
+}
+
+// masker применяет правила маскирования к записям
+type masker struct {
+	key   []byte
+	rules map[string]maskRule // Ключ - имя колонки в нижнем регистре
+}
+
+// WithMasking включает маскирование колонок. Ключ обязателен для hmac и format_preserving
+func WithMasking(key string, rules []config.MaskRuleConfig) (ProcessorOption, error) {
+	m := &masker{
+		key:   []byte(key),
+		rules: make(map[string]maskRule, len(rules)),
+	}
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		if (r.Method == MaskHMAC || r.Method == MaskFormatPreserving) && key == "" {
+			return nil, fmt.Errorf("column %s: masking key is required for method %s", r.Column, r.Method)
+		}
+		rule := maskRule{
+			method:     r.Method,
+			keepPrefix: r.KeepPrefix,
+			keepSuffix: r.KeepSuffix,
+			maskChar:   '*',
+			length:     r.Length,
+		}
+		if r.MaskChar != "" {
+			rule.maskChar = []rune(r.MaskChar)[0]
+		}
+		m.rules[strings.ToLower(r.Column)] = rule
+	}
+
+	return func(p *DataProcessor) {
+		p.masker = m
+	}, nil
+}
+
+// apply маскирует значения записи
+func (m *masker) apply(record domain.Record) {
+	for name, value := range record {
+		rule, ok := m.rules[strings.ToLower(name)]
+		if !ok || value == nil {
+			continue
+		}
+		record[name] = m.mask(rule, value)
+	}
+}
+
+func (m *masker) mask(rule maskRule, value interface{}) interface{} {
+	str := valueString(value)
+	switch rule.method {
+	case MaskHMAC:
+		// Числовой колонке hex-строка не подходит: для чисел хеш состоит из цифр
+		switch value.(type) {
+		case int64, domain.Decimal:
+			return restoreType(value, m.hmacDigits(str, numericDigestLength(value, rule.length)))
+		}
+		sum := m.hmac(str)
+		if rule.length > 0 && rule.length < len(sum) {
+			sum = sum[:rule.length]
+		}
+		return sum
+	case MaskFormatPreserving:
+		return restoreType(value, m.formatPreserving(str, rule.keepPrefix))
+	case MaskRedact:
+		return redact(str, rule.keepPrefix, rule.keepSuffix, rule.maskChar)
+	default:
+		return nil
+	}
+}
+
+func (m *masker) hmac(value string) string {
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hmacDigits возвращает хеш значения из n цифр. Первая цифра не нулевая,
+// чтобы результат оставался числом той же длины
+func (m *masker) hmacDigits(value string, n int) string {
+	stream := m.hmacStream(value, n)
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = '0' + stream[i]%10
+	}
+	out[0] = '1' + stream[0]%9
+	return string(out)
+}
+
+// numericDigestLength возвращает длину числового хеша: length из правила или
+// количество цифр исходного значения. Для int64 длина ограничена 18 цифрами
+func numericDigestLength(value interface{}, length int) int {
+	n := length
+	if n <= 0 {
+		for _, r := range valueString(value) {
+			if r >= '0' && r <= '9' {
+				n++
+			}
+		}
+	}
+	if _, ok := value.(int64); ok && n > 18 {
+		n = 18
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// hmacStream возвращает не меньше n байт HMAC значения. При длинных значениях
+// поток продлевается хешированием предыдущего блока
+func (m *masker) hmacStream(value string, n int) []byte {
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write([]byte(value))
+	stream := mac.Sum(nil)
+	for len(stream) < n {
+		next := sha256.Sum256(stream)
+		stream = append(stream, next[:]...)
+	}
+	return stream
+}
+
+// formatPreserving заменяет цифры после первых keepPrefix (код страны, MCC+MNC)
+// цифрами, полученными из HMAC исходного значения. Длина, нецифровые символы
+// и префикс сохраняются, одинаковые значения дают одинаковый результат
+func (m *masker) formatPreserving(value string, keepPrefix int) string {
+	out := []rune(value)
+	stream := m.hmacStream(value, len(out))
+	digit := 0
+	for i, r := range out {
+		if r < '0' || r > '9' {
+			continue
+		}
+		if digit >= keepPrefix {
+			out[i] = rune('0' + stream[digit-keepPrefix]%10)
+		}
+		digit++
+	}
+	return string(out)
+}
+
+// redact скрывает символы, оставляя keepPrefix в начале и keepSuffix в конце
+func redact(value string, keepPrefix, keepSuffix int, maskChar rune) string {
+	runes := []rune(value)
+	for i := range runes {
+		if i >= keepPrefix && i < len(runes)-keepSuffix {
+			runes[i] = maskChar
+		}
+	}
+	return string(runes)
+}
+
+// valueString возвращает строковое представление значения для маскирования
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case domain.Decimal:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// restoreType возвращает маскированное значение в исходном типе (числа остаются числами).
+// Результат с ведущим нулем остается строкой: число потеряло бы ноль и длину
+func restoreType(original interface{}, masked string) interface{} {
+	if len(masked) > 1 && masked[0] == '0' {
+		switch original.(type) {
+		case int64, domain.Decimal:
+			return masked
+		}
+	}
+	switch original.(type) {
+	case int64:
+		if n, err := strconv.ParseInt(masked, 10, 64); err == nil {
+			return n
+		}
+	case domain.Decimal:
+		if d, err := domain.ParseDecimal(masked); err == nil {
+			return d
+		}
+	case []byte:
+		return []byte(masked)
+	}
+	return masked
+}
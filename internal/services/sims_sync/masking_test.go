@@ -0,0 +1,176 @@
+package sims_sync
+
+import (
+	"db_swapper/internal/config"
+	"db_swapper/internal/domain"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newTestMasker(t *testing.T, key string, rules ...config.MaskRuleConfig) *masker {
+	t.Helper()
+	opt, err := WithMasking(key, rules)
+	if err != nil {
+		t.Fatalf("WithMasking: %v", err)
+	}
+	p := NewDataProcessor(0, opt)
+	return p.masker
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func TestMaskingIsDeterministic(t *testing.T) {
+	rules := []config.MaskRuleConfig{
+		{Column: "email", Method: MaskHMAC},
+		{Column: "msisdn", Method: MaskFormatPreserving, KeepPrefix: 4},
+		{Column: "imsi", Method: MaskHMAC},
+	}
+	record := func() domain.Record {
+		return domain.Record{"EMAIL": "user@example.com", "MSISDN": "+7 (916) 123-45-67", "IMSI": int64(250011234567890)}
+	}
+
+	a, b := record(), record()
+	newTestMasker(t, "secret", rules...).apply(a)
+	newTestMasker(t, "secret", rules...).apply(b)
+	for col := range a {
+		if a[col] != b[col] {
+			t.Errorf("%s: %v and %v for the same input and key", col, a[col], b[col])
+		}
+		if a[col] == record()[col] {
+			t.Errorf("%s was not masked", col)
+		}
+	}
+
+	other := record()
+	newTestMasker(t, "another secret", rules...).apply(other)
+	for col := range a {
+		if a[col] == other[col] {
+			t.Errorf("%s: same pseudonym %v for different keys", col, a[col])
+		}
+	}
+
+	next := domain.Record{"EMAIL": "other@example.com", "MSISDN": "+7 (916) 123-45-68", "IMSI": int64(250011234567891)}
+	newTestMasker(t, "secret", rules...).apply(next)
+	for col := range a {
+		if a[col] == next[col] {
+			t.Errorf("%s: same pseudonym %v for different values", col, a[col])
+		}
+	}
+}
+
+func TestMaskingKeepsNumbersNumeric(t *testing.T) {
+	dec := func(s string) domain.Decimal {
+		d, err := domain.ParseDecimal(s)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q): %v", s, err)
+		}
+		return d
+	}
+	tests := []struct {
+		name   string
+		rule   config.MaskRuleConfig
+		value  interface{}
+		digits int
+	}{
+		{"hmac int64", config.MaskRuleConfig{Method: MaskHMAC}, int64(250011234567890), 15},
+		{"hmac int64 with length", config.MaskRuleConfig{Method: MaskHMAC, Length: 8}, int64(250011234567890), 8},
+		{"hmac int64 over 18 digits", config.MaskRuleConfig{Method: MaskHMAC, Length: 30}, int64(1), 18},
+		{"hmac decimal", config.MaskRuleConfig{Method: MaskHMAC}, dec("89701012345678901234"), 20},
+		{"format preserving int64", config.MaskRuleConfig{Method: MaskFormatPreserving, KeepPrefix: 5}, int64(250011234567890), 15},
+		{"format preserving decimal", config.MaskRuleConfig{Method: MaskFormatPreserving}, dec("79161234567"), 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.Column = "value"
+			m := newTestMasker(t, "secret", tt.rule)
+			// Разные значения дают разные результаты, включая начинающиеся с нуля
+			for i := 0; i < 50; i++ {
+				value := tt.value
+				switch v := value.(type) {
+				case int64:
+					value = v + int64(i)
+				case domain.Decimal:
+					s := v.String()
+					value = dec(fmt.Sprintf("%s%d", s[:len(s)-2], 10+i))
+				}
+				record := domain.Record{"value": value}
+				m.apply(record)
+
+				var masked string
+				switch v := record["value"].(type) {
+				case int64:
+					masked = fmt.Sprint(v)
+				case domain.Decimal:
+					masked = v.String()
+				case string:
+					// Результат с ведущим нулем остается строкой
+					if !strings.HasPrefix(v, "0") {
+						t.Fatalf("%v masked to string %q without a leading zero", value, v)
+					}
+					masked = v
+				default:
+					t.Fatalf("%v masked to %T", value, v)
+				}
+				if !isDigits(masked) || len(masked) != tt.digits {
+					t.Errorf("%v masked to %q, want %d digits", value, masked, tt.digits)
+				}
+			}
+		})
+	}
+}
+
+func TestMaskingKeepsLeadingZeros(t *testing.T) {
+	m := newTestMasker(t, "secret",
+		config.MaskRuleConfig{Column: "iccid", Method: MaskFormatPreserving, KeepPrefix: 2},
+		config.MaskRuleConfig{Column: "code", Method: MaskFormatPreserving},
+	)
+
+	record := domain.Record{"iccid": "0089701012345678901"}
+	m.apply(record)
+	iccid := record["iccid"].(string)
+	if !strings.HasPrefix(iccid, "00") || len(iccid) != 19 || !isDigits(iccid) {
+		t.Errorf("iccid masked to %q, want 19 digits starting with 00", iccid)
+	}
+
+	// Числовое значение, маска которого начинается с нуля, не теряет ноль и длину
+	found := false
+	for n := int64(1000000); n < 1000100; n++ {
+		record := domain.Record{"code": n}
+		m.apply(record)
+		if s, ok := record["code"].(string); ok {
+			found = true
+			if len(s) != 7 || s[0] != '0' || !isDigits(s) {
+				t.Errorf("%d masked to %q", n, s)
+			}
+		}
+	}
+	if !found {
+		t.Error("no masked value started with zero in 100 samples")
+	}
+}
+
+func TestMaskingRedactAndNull(t *testing.T) {
+	m := newTestMasker(t, "",
+		config.MaskRuleConfig{Column: "name", Method: MaskRedact, KeepPrefix: 1, KeepSuffix: 1},
+		config.MaskRuleConfig{Column: "passport", Method: MaskNull},
+	)
+	record := domain.Record{"name": "Иванов", "passport": "4510 123456", "id": int64(7)}
+	m.apply(record)
+	if record["name"] != "И****в" {
+		t.Errorf("name = %v, want И****в", record["name"])
+	}
+	if record["passport"] != nil {
+		t.Errorf("passport = %v, want nil", record["passport"])
+	}
+	if record["id"] != int64(7) {
+		t.Errorf("unmasked column changed: %v", record["id"])
+	}
+}
@@ -1,7 +1,9 @@
 package sims_sync
 
 import (
+	"context"
 	"db_swapper/internal/domain"
+	"strings"
 	"sync"
 )
 
@@ -16,6 +18,12 @@ type DataProcessor struct {
 	columnMapping map[string]string
 	sqlOpts       []sqlOption
 	dataLoaded    bool // Флаг, указывающий что данные были предзагружены
+	timeZones     *timeZoneRules
+	masker        *masker
+	lookups       []*Lookup
+	skipped       int   // Количество записей, отброшенных из-за превышения лимита LOB
+	rejected      int   // Количество записей, отброшенных справочниками
+	err           error // Первая ошибка обработки за прогон
 }
 
 type ProcessorOption func(*DataProcessor)
@@ -58,12 +66,12 @@ func (p *DataProcessor) HasPreloadedData() bool {
 }
 
 // Process обрабатывает данные, учитывая предзагруженные данные и схемы
-func (p *DataProcessor) Process(batch []domain.Record) {
+func (p *DataProcessor) Process(ctx context.Context, batch []domain.Record) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	for _, record := range batch {
-		processed := p.processRecord(record)
+		processed := p.processRecord(ctx, record)
 		if processed != nil {
 			p.buffer = append(p.buffer, processed)
 		}
@@ -71,13 +79,19 @@ func (p *DataProcessor) Process(batch []domain.Record) {
 }
 
 // processRecord обрабатывает одну запись с учетом схем и маппинга
-func (p *DataProcessor) processRecord(record domain.Record) domain.Record {
+func (p *DataProcessor) processRecord(ctx context.Context, record domain.Record) domain.Record {
+	// Коннектор пометил значение, превысившее лимит LOB (политика skip_row)
+	if record.HasOversized() {
+		p.skipped++
+		return nil
+	}
+
 	// Если нет схемы источника, просто применяем трансформацию
 	if p.sourceSchema == nil {
 		if p.transform != nil {
-			return p.transform(record)
+			return p.finalize(ctx, p.transform(record))
 		}
-		return record
+		return p.finalize(ctx, record)
 	}
 
 	processed := make(domain.Record)
@@ -110,14 +124,41 @@ func (p *DataProcessor) processRecord(record domain.Record) domain.Record {
 
 	// Применяем трансформацию если задана
 	if p.transform != nil {
-		return p.transform(processed)
+		return p.finalize(ctx, p.transform(processed))
 	}
 
-	return processed
+	return p.finalize(ctx, processed)
+}
+
+// finalize применяет шаги, работающие с итоговыми именами колонок цели
+func (p *DataProcessor) finalize(ctx context.Context, record domain.Record) domain.Record {
+	if record == nil {
+		return nil
+	}
+	for _, l := range p.lookups {
+		ok, err := l.enrich(ctx, record)
+		if err != nil {
+			if p.err == nil {
+				p.err = err
+			}
+			return nil
+		}
+		if !ok {
+			p.rejected++
+			return nil
+		}
+	}
+	if p.masker != nil {
+		p.masker.apply(record)
+	}
+	if p.timeZones != nil {
+		p.timeZones.apply(record)
+	}
+	return record
 }
 
 // GetPreloadedBatch возвращает пакет предзагруженных данных с обработкой
-func (p *DataProcessor) GetPreloadedBatch(offset, batchSize int) []domain.Record {
+func (p *DataProcessor) GetPreloadedBatch(ctx context.Context, offset, batchSize int) []domain.Record {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -133,7 +174,7 @@ func (p *DataProcessor) GetPreloadedBatch(offset, batchSize int) []domain.Record
 	// Обрабатываем каждую запись в пакете
 	var processedBatch []domain.Record
 	for _, record := range p.sourceData[offset:end] {
-		processed := p.processRecord(record)
+		processed := p.processRecord(ctx, record)
 		if processed != nil {
 			processedBatch = append(processedBatch, processed)
 		}
@@ -170,6 +211,30 @@ func (p *DataProcessor) createColumnMapping() {
 	}
 }
 
+// SourceColumnFor возвращает имя колонки источника для колонки цели
+func (p *DataProcessor) SourceColumnFor(target string) string {
+	for src, tgt := range p.columnMapping {
+		if strings.EqualFold(tgt, target) {
+			return src
+		}
+	}
+	if p.sourceSchema != nil {
+		for _, col := range p.sourceSchema.Columns {
+			if col.GetColumnName(true) == strings.ToLower(strings.ReplaceAll(target, "_", "")) {
+				return col.Name
+			}
+		}
+	}
+	return target
+}
+
+// PreloadedData возвращает предзагруженные данные источника
+func (p *DataProcessor) PreloadedData() []domain.Record {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sourceData
+}
+
 func (p *DataProcessor) GetBatch(size int) []domain.Record {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -184,6 +249,48 @@ func (p *DataProcessor) GetBatch(size int) []domain.Record {
 	return batch
 }
 
+// SkippedCount возвращает количество отброшенных записей с начала прогона
+func (p *DataProcessor) SkippedCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.skipped
+}
+
+// RejectedCount возвращает количество записей, отброшенных справочниками
+func (p *DataProcessor) RejectedCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rejected
+}
+
+// Err возвращает первую ошибку обработки с начала прогона
+func (p *DataProcessor) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// ResetStats сбрасывает счетчики перед новым прогоном синхронизации
+func (p *DataProcessor) ResetStats() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.skipped = 0
+	p.rejected = 0
+	p.err = nil
+}
+
+// LoadLookups (пере)загружает справочники перед прогоном
+func (p *DataProcessor) LoadLookups(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, l := range p.lookups {
+		if err := l.Load(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *DataProcessor) BufferSize() int {
 	p.mu.Lock()
 	defer p.mu.Unlock()
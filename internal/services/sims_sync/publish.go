@@ -0,0 +1,195 @@
+package sims_sync
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/domain"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Суффиксы физических таблиц в режиме blue_green
+const (
+	blueSuffix  = "_a"
+	greenSuffix = "_b"
+)
+
+// partitionExchanger реализуется коннекторами с поддержкой обмена секций (Oracle)
+type partitionExchanger interface {
+	ExchangePartition(table, staging string, p connectors.PartitionSpec) error
+	GatherPartitionStats(table string, p connectors.PartitionSpec) error
+}
+
+// checkPublishMode проверяет, что цель поддерживает выбранный способ публикации
+func checkPublishMode(target connectors.DatabaseConnector, cfg config.SyncConfig) error {
+	if cfg.PublishMode != config.PublishPartitionExchange {
+		return nil
+	}
+	if _, ok := connectors.Unwrap(target).(partitionExchanger); !ok {
+		return fmt.Errorf("publish_mode %s is supported only for Oracle targets", cfg.PublishMode)
+	}
+	if cfg.Partition == nil {
+		return fmt.Errorf("publish_mode %s requires partition settings", cfg.PublishMode)
+	}
+	return nil
+}
+
+// partitionSpec возвращает секцию для обмена. Значения today/yesterday
+// вычисляются в часовом поясе цели на момент прогона
+func (s *SyncService) partitionSpec() connectors.PartitionSpec {
+	p := s.config.Partition
+	spec := connectors.PartitionSpec{
+		Name:             p.Name,
+		KeyValue:         p.KeyValue,
+		IncludingIndexes: p.IncludingIndexes,
+		WithValidation:   p.WithValidation,
+	}
+	now := time.Now().In(connectorLocation(s.target))
+	switch strings.ToLower(p.KeyValue) {
+	case "today":
+		spec.KeyValue = fmt.Sprintf("DATE '%s'", now.Format("2006-01-02"))
+	case "yesterday":
+		spec.KeyValue = fmt.Sprintf("DATE '%s'", now.AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	return spec
+}
+
+// stagingSchema возвращает схему промежуточной таблицы. Для обмена секций
+// таблица копируется с секционированной (CREATE TABLE AS SELECT), чтобы типы совпали
+func (s *SyncService) stagingSchema() *domain.TableSchema {
+	if s.config.PublishMode == config.PublishPartitionExchange {
+		return &domain.TableSchema{}
+	}
+	schema := s.processor.targetSchema
+	if !s.deferIndexes() {
+		return schema
+	}
+	bare := *schema
+	bare.PrimaryKey = ""
+	bare.Indexes = nil
+	return &bare
+}
+
+// deferIndexes сообщает, что ключ и индексы строятся после загрузки.
+// В MariaDB колонка AUTO_INCREMENT должна входить в ключ при создании таблицы,
+// поэтому для таких схем индексы создаются сразу
+func (s *SyncService) deferIndexes() bool {
+	schema := s.processor.targetSchema
+	if !s.config.DeferIndexes || schema == nil || len(schema.Columns) == 0 ||
+		s.config.PublishMode == config.PublishPartitionExchange {
+		return false
+	}
+	for _, col := range schema.Columns {
+		if col.AutoIncrement {
+			return false
+		}
+	}
+	return true
+}
+
+// buildIndexes создает отложенные ключ и индексы и обновляет статистику промежуточной таблицы
+func (s *SyncService) buildIndexes(ctx context.Context, tableName string) error {
+	if !s.deferIndexes() {
+		return nil
+	}
+	started := time.Now()
+	err := s.target.BuildIndexes(ctx, tableName, s.processor.targetSchema, func(done, total int, index string) {
+		s.logger.Info(fmt.Sprintf("Index %d/%d built on %s: %s (%s elapsed)",
+			done, total, tableName, index, time.Since(started).Round(time.Second)))
+	})
+	if err != nil {
+		return fmt.Errorf("build indexes failed: %w", err)
+	}
+	if err := s.target.AnalyzeTable(ctx, tableName); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to analyze table %s: %v", tableName, err))
+	}
+	return nil
+}
+
+// cloneMetadata переносит на загруженную таблицу объекты опубликованной таблицы.
+// Выполняется после загрузки, чтобы триггеры не срабатывали на вставку данных
+func (s *SyncService) cloneMetadata(ctx context.Context, staging, active string) error {
+	if !s.config.CloneMetadata || s.config.PublishMode == config.PublishPartitionExchange {
+		return nil
+	}
+	report, err := s.target.CloneMetadata(ctx, active, staging)
+	if err != nil {
+		return fmt.Errorf("clone metadata failed: %w", err)
+	}
+	for _, c := range report.Copied {
+		s.logger.Info(fmt.Sprintf("Metadata copied to %s: %s", staging, c))
+	}
+	for _, c := range report.Skipped {
+		s.logger.Info(fmt.Sprintf("Metadata skipped for %s: %s", staging, c))
+	}
+	s.logger.Info(fmt.Sprintf("Metadata of %s cloned: %d copied, %d skipped", active, len(report.Copied), len(report.Skipped)))
+	return nil
+}
+
+// blueGreen сообщает, что таблица публикуется через представление/синоним
+func (s *SyncService) blueGreen() bool {
+	return s.config.PublishMode == config.PublishBlueGreen
+}
+
+// stagingTable возвращает таблицу для загрузки и физическую таблицу, опубликованную сейчас.
+// В режиме blue_green загрузка идет в ту из таблиц _a/_b, которая сейчас не опубликована
+func (s *SyncService) stagingTable() (string, string, error) {
+	if !s.blueGreen() {
+		return s.config.Target.Table + s.config.TempTableSuffix, s.config.Target.Table, nil
+	}
+	active, err := s.target.PublishedTable(s.config.Target.Table)
+	if err != nil {
+		return "", "", fmt.Errorf("resolve published table failed: %w", err)
+	}
+	staging := InactiveTable(s.config.Target.Table, active)
+	if active == "" {
+		// Первый переход: текущая версия - обычная таблица под именем цели (или ее еще нет)
+		active = s.config.Target.Table
+	}
+	return staging, active, nil
+}
+
+// InactiveTable возвращает физическую таблицу blue_green, на которую name сейчас не указывает
+func InactiveTable(name, active string) string {
+	if strings.EqualFold(active, name+blueSuffix) {
+		return name + greenSuffix
+	}
+	return name + blueSuffix
+}
+
+// publish делает загруженную таблицу видимой под именем целевой таблицы
+func (s *SyncService) publish(staging, active string) error {
+	if s.config.PublishMode == config.PublishPartitionExchange {
+		spec := s.partitionSpec()
+		exchanger := connectors.Unwrap(s.target).(partitionExchanger)
+		if err := exchanger.ExchangePartition(s.config.Target.Table, staging, spec); err != nil {
+			return fmt.Errorf("partition exchange failed: %w", err)
+		}
+		s.logger.Info(fmt.Sprintf("Partition %s%s of %s exchanged", spec.Name, spec.KeyValue, s.config.Target.Table))
+		if gather := s.config.Partition.GatherStats; gather == nil || *gather {
+			if err := exchanger.GatherPartitionStats(s.config.Target.Table, spec); err != nil {
+				s.logger.Error(fmt.Sprintf("failed to gather partition stats: %v", err))
+			}
+		}
+		return nil
+	}
+	if !s.blueGreen() {
+		// Меняем таблицы местами (исходную и ту то что мы создали). Создаем бекап таблицы
+		if err := s.target.SwapTables(s.config.Target.Table, staging); err != nil {
+			return fmt.Errorf("table swap failed: %w", err)
+		}
+		return nil
+	}
+
+	// Права копируются с опубликованной таблицы (или с обычной таблицы при первом переходе)
+	if err := s.target.CopyGrants(active, staging); err != nil {
+		return fmt.Errorf("copy grants failed: %w", err)
+	}
+	if err := s.target.PublishTable(s.config.Target.Table, staging); err != nil {
+		return fmt.Errorf("publish failed: %w", err)
+	}
+	s.logger.Info(fmt.Sprintf("Table %s published from %s", s.config.Target.Table, staging))
+	return nil
+}
@@ -0,0 +1,203 @@
+package sims_sync
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/domain"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Колонки таблицы отказов
+var rejectColumns = []string{"sync_table", "source_key", "error_message", "payload", "rejected_at"}
+
+// rejectedRow - строка, которую не удалось загрузить
+type rejectedRow struct {
+	Table      string        `json:"table"`
+	SourceKey  string        `json:"source_key"`
+	Error      string        `json:"error"`
+	Record     domain.Record `json:"record"`
+	RejectedAt time.Time     `json:"rejected_at"`
+}
+
+// rejectSink сохраняет отклоненные строки
+type rejectSink interface {
+	Write(ctx context.Context, row rejectedRow) error
+}
+
+// rejectHandler изолирует плохие строки пачки и следит за порогами отказов
+type rejectHandler struct {
+	cfg       config.RejectConfig
+	table     string   // Целевая таблица синхронизации
+	keyFields []string // Колонки ключа записи для source_key
+	sinks     []rejectSink
+
+	mu    sync.Mutex
+	count int
+}
+
+// newRejectHandler создает обработчик отказов. Таблица отказов создается в целевой БД
+func newRejectHandler(target connectors.DatabaseConnector, cfg config.RejectConfig, table, primaryKey string) (*rejectHandler, error) {
+	h := &rejectHandler{cfg: cfg, table: table}
+	for _, k := range strings.Split(primaryKey, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			h.keyFields = append(h.keyFields, k)
+		}
+	}
+
+	if cfg.Table != "" {
+		sink, err := newTableRejectSink(target, cfg.Table)
+		if err != nil {
+			return nil, err
+		}
+		h.sinks = append(h.sinks, sink)
+	}
+	if cfg.File != "" {
+		h.sinks = append(h.sinks, &fileRejectSink{path: cfg.File})
+	}
+	return h, nil
+}
+
+// reset обнуляет счетчик перед новым прогоном
+func (h *rejectHandler) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count = 0
+}
+
+// Count возвращает количество отказов за прогон
+func (h *rejectHandler) Count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// reject сохраняет строку и возвращает ошибку при превышении max_rejects
+func (h *rejectHandler) reject(ctx context.Context, record domain.Record, cause error) error {
+	row := rejectedRow{
+		Table:      h.table,
+		SourceKey:  h.sourceKey(record),
+		Error:      cause.Error(),
+		Record:     record,
+		RejectedAt: time.Now(),
+	}
+	for _, sink := range h.sinks {
+		if err := sink.Write(ctx, row); err != nil {
+			return fmt.Errorf("write reject failed: %w", err)
+		}
+	}
+
+	h.mu.Lock()
+	h.count++
+	count := h.count
+	h.mu.Unlock()
+
+	if h.cfg.MaxRejects > 0 && count > h.cfg.MaxRejects {
+		return fmt.Errorf("rejected rows limit exceeded: %d > %d, last error: %w", count, h.cfg.MaxRejects, cause)
+	}
+	return nil
+}
+
+// checkPercent проверяет долю отказов от общего числа строк в конце прогона
+func (h *rejectHandler) checkPercent(total int) error {
+	count := h.Count()
+	if h.cfg.MaxRejectPercent <= 0 || total == 0 {
+		return nil
+	}
+	percent := float64(count) * 100 / float64(total)
+	if percent > h.cfg.MaxRejectPercent {
+		return fmt.Errorf("rejected rows percent exceeded: %.2f%% > %.2f%% (%d of %d)",
+			percent, h.cfg.MaxRejectPercent, count, total)
+	}
+	return nil
+}
+
+// sourceKey собирает ключ записи вида "ID=1,CODE=A"
+func (h *rejectHandler) sourceKey(record domain.Record) string {
+	parts := make([]string, 0, len(h.keyFields))
+	for _, k := range h.keyFields {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, valueString(fieldValue(record, k))))
+	}
+	return strings.Join(parts, ",")
+}
+
+// tableRejectSink пишет отказы в таблицу целевой БД
+type tableRejectSink struct {
+	conn  connectors.DatabaseConnector
+	table string
+}
+
+func newTableRejectSink(conn connectors.DatabaseConnector, table string) (*tableRejectSink, error) {
+	textType, lobType, timeType := "VARCHAR(4000)", "LONGTEXT", "DATETIME"
+	if connectors.Dialect(conn) == connectors.DialectOracle {
+		textType, lobType, timeType = "VARCHAR2(4000)", "CLOB", "DATE"
+	}
+	schema := &domain.TableSchema{
+		Columns: []domain.ColumnInfo{
+			{Name: "sync_table", DataType: "VARCHAR(255)"},
+			{Name: "source_key", DataType: textType, IsNullable: true},
+			{Name: "error_message", DataType: textType, IsNullable: true},
+			{Name: "payload", DataType: lobType, IsNullable: true},
+			{Name: "rejected_at", DataType: timeType},
+		},
+	}
+	if err := conn.CreateTableIfNotExists(table, schema); err != nil {
+		return nil, fmt.Errorf("create reject table %s failed: %w", table, err)
+	}
+	return &tableRejectSink{conn: conn, table: table}, nil
+}
+
+func (s *tableRejectSink) Write(ctx context.Context, row rejectedRow) error {
+	payload, err := json.Marshal(row.Record)
+	if err != nil {
+		return fmt.Errorf("marshal rejected record failed: %w", err)
+	}
+	record := domain.Record{
+		"sync_table":    row.Table,
+		"source_key":    truncateString(row.SourceKey, 4000),
+		"error_message": truncateString(row.Error, 4000),
+		"payload":       string(payload),
+		"rejected_at":   row.RejectedAt,
+	}
+	return s.conn.InsertBatch(ctx, s.table, []domain.Record{record}, rejectColumns)
+}
+
+// fileRejectSink дописывает отказы в JSONL-файл
+type fileRejectSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (s *fileRejectSink) Write(_ context.Context, row rejectedRow) error {
+	line, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Errorf("marshal rejected row failed: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("open reject file failed: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// truncateString обрезает строку до max байт, не разрывая символ UTF-8
+func truncateString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
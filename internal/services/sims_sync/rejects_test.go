@@ -0,0 +1,103 @@
+package sims_sync
+
+import (
+	"bufio"
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/domain"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInsertBatchRejectsOnlyBadRow(t *testing.T) {
+	ctx := context.Background()
+	target := &fakeConnector{insertFn: func(r domain.Record) error {
+		if r["id"] == int64(3) {
+			return errors.New("value too large for column imsi")
+		}
+		return nil
+	}}
+	rejectsConn := &fakeConnector{}
+	file := filepath.Join(t.TempDir(), "rejects.jsonl")
+	rejects, err := newRejectHandler(rejectsConn, config.RejectConfig{Table: "sims_rejects", File: file}, "sims", "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestService(t, nil, target, NewDataProcessor(0), config.SyncConfig{})
+	s.rejects = rejects
+	var records []domain.Record
+	for id := int64(1); id <= 5; id++ {
+		records = append(records, domain.Record{"id": id, "imsi": "25001000000000"})
+	}
+	if err := s.insertBatch(ctx, "sims_temp", records); err != nil {
+		t.Fatalf("insertBatch: %v", err)
+	}
+
+	var loaded []int64
+	for _, r := range target.inserted {
+		loaded = append(loaded, r["id"].(int64))
+	}
+	if want := []int64{1, 2, 4, 5}; !reflect.DeepEqual(loaded, want) {
+		t.Errorf("loaded ids %v, want %v", loaded, want)
+	}
+	if got := rejects.Count(); got != 1 {
+		t.Errorf("rejected %d rows, want 1", got)
+	}
+
+	if len(rejectsConn.inserted) != 1 {
+		t.Fatalf("reject table got %d rows, want 1", len(rejectsConn.inserted))
+	}
+	row := rejectsConn.inserted[0]
+	if row["sync_table"] != "sims" || row["source_key"] != "id=3" || row["error_message"] != "value too large for column imsi" {
+		t.Errorf("reject table row = %v", row)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []rejectedRow
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line rejectedRow
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("reject file line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 1 || lines[0].SourceKey != "id=3" {
+		t.Errorf("reject file = %+v, want one row with id=3", lines)
+	}
+}
+
+func TestInsertBatchStopsAtMaxRejects(t *testing.T) {
+	target := &fakeConnector{insertFn: func(r domain.Record) error {
+		if r["id"].(int64)%2 == 0 {
+			return errors.New("duplicate entry")
+		}
+		return nil
+	}}
+	rejects, err := newRejectHandler(&fakeConnector{}, config.RejectConfig{MaxRejects: 1}, "sims", "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestService(t, nil, target, NewDataProcessor(0), config.SyncConfig{})
+	s.rejects = rejects
+
+	var records []domain.Record
+	for id := int64(1); id <= 4; id++ {
+		records = append(records, domain.Record{"id": id})
+	}
+	if err := s.insertBatch(context.Background(), "sims_temp", records); err == nil {
+		t.Fatal("insertBatch succeeded with more rejects than max_rejects")
+	}
+	if got := rejects.Count(); got != 2 {
+		t.Errorf("rejected %d rows, want 2", got)
+	}
+}
@@ -1,11 +1,16 @@
 package sims_sync
 
 import (
+	"context"
 	"db_swapper/internal/config"
 	"db_swapper/internal/connectors"
 	"db_swapper/internal/domain"
+	"db_swapper/internal/scheduler"
+	"errors"
 	"fmt"
 	"logger"
+	"os"
+	"strings"
 	"time"
 )
 
@@ -18,6 +23,10 @@ type SyncService struct {
 
 	sourceSchema *domain.TableSchema
 	targetSchema *domain.TableSchema
+
+	rejects   *rejectHandler // nil, если плохие строки не изолируются
+	validator *validator     // nil, если проверки качества не настроены
+	verifier  *verifier      // nil, если сверка не настроена
 }
 
 func NewSyncService(
@@ -74,6 +83,56 @@ func NewSyncService(
 		service.targetSchema = schema
 	}
 
+	if err := checkPublishMode(target, cfg); err != nil {
+		return nil, err
+	}
+	// Маскирование целевой БД добавляется к правилам задачи только здесь
+	if err := cfg.ValidateVerifyColumns(); err != nil {
+		return nil, fmt.Errorf("invalid verify: %w", err)
+	}
+
+	// Нормализация часовых поясов
+	if cfg.StoreAsUTC || len(cfg.TimeZones) > 0 {
+		tzOpt, err := WithTimeZones(connectorLocation(source), connectorLocation(target), cfg.StoreAsUTC, cfg.TimeZones)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zones: %w", err)
+		}
+		opts = append(opts, tzOpt)
+	}
+
+	// Маскирование персональных данных
+	if len(cfg.Masking) > 0 {
+		key := cfg.MaskingKey
+		if key == "" {
+			key = os.Getenv("DB_SWAPPER_MASKING_KEY")
+		}
+		maskOpt, err := WithMasking(key, cfg.Masking)
+		if err != nil {
+			return nil, fmt.Errorf("invalid masking: %w", err)
+		}
+		opts = append(opts, maskOpt)
+	}
+
+	// Изоляция плохих строк
+	if cfg.Rejects != nil {
+		keyColumns := cfg.Target.PrimaryKey
+		if keyColumns == "" {
+			keyColumns = cfg.Source.PrimaryKey
+		}
+		rejects, err := newRejectHandler(target, *cfg.Rejects, cfg.Target.Table, keyColumns)
+		if err != nil {
+			return nil, fmt.Errorf("init rejects failed: %w", err)
+		}
+		service.rejects = rejects
+	}
+
+	if cfg.Validation != nil {
+		service.validator = newValidator(target, *cfg.Validation)
+	}
+	if cfg.Verify != nil {
+		service.verifier = newVerifier(*cfg.Verify)
+	}
+
 	// Создаем временный процессор для извлечения опций
 	tmpProcessor := NewDataProcessor(0, opts...)
 
@@ -81,8 +140,8 @@ func NewSyncService(
 	for _, sqlOpt := range tmpProcessor.sqlOpts {
 		if sqlOpt.isSource {
 			if sqlOpt.returnData {
-				// Получаем данные для источника
-				data, err := source.ExecuteSelect(sqlOpt.query, sqlOpt.args...)
+				// Получаем данные для источника. Загрузка идет при создании сервиса, вне прогона задачи
+				data, err := source.ExecuteSelect(context.Background(), sqlOpt.query, sqlOpt.args...)
 				if err != nil {
 					logger.Errorf("Failed to get source data from SQL: %v", err)
 					continue
@@ -109,6 +168,9 @@ func NewSyncService(
 		}
 	}
 
+	// Ограничения размера LOB применяются коннектором при чтении пачек
+	withLOBLimits(service.sourceSchema, cfg.LOBLimits)
+
 	// Создаем финальный процессор с актуальными схемами
 	processorOpts := []ProcessorOption{
 		WithSchemas(service.sourceSchema, service.targetSchema),
@@ -131,7 +193,15 @@ func NewSyncService(
 	return service, nil
 }
 
-func (s *SyncService) processData(tempTableName string) error {
+// connectorLocation возвращает пояс хранения дат коннектора (локальный, если неизвестен)
+func connectorLocation(conn connectors.DatabaseConnector) *time.Location {
+	if lp, ok := connectors.Unwrap(conn).(locationProvider); ok {
+		return lp.Location()
+	}
+	return time.Local
+}
+
+func (s *SyncService) processData(ctx context.Context, tempTableName string) error {
 	var totalCount int
 	var err error
 	// Используем предзагруженные данные если они есть
@@ -141,24 +211,27 @@ func (s *SyncService) processData(tempTableName string) error {
 
 		batchSize := s.config.BatchSize
 		for offset := 0; offset < totalCount; offset += batchSize {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("sync aborted: %w", err)
+			}
 
-			processedBatch := s.processor.GetPreloadedBatch(offset, batchSize)
+			processedBatch := s.processor.GetPreloadedBatch(ctx, offset, batchSize)
+			if err := s.processor.Err(); err != nil {
+				return fmt.Errorf("process records failed: %w", err)
+			}
+			// Справочники могут отбросить всю пачку: остальные пачки все равно загружаются
 			if len(processedBatch) == 0 {
-				break
+				continue
 			}
-			if err := s.target.InsertBatch(
-				tempTableName,
-				processedBatch,
-				s.processor.GetTargetColumns(),
-			); err != nil {
-				return fmt.Errorf("insert batch failed: %w", err)
+			if err := s.insertBatch(ctx, tempTableName, processedBatch); err != nil {
+				return err
 			}
 
 			s.logger.Info(fmt.Sprintf("Progress: %d/%d records processed", offset+len(processedBatch), totalCount))
 		}
 	} else {
 
-		totalCount, err = s.source.GetCount(s.sourceSchema)
+		totalCount, err = s.source.GetCount(ctx, s.sourceSchema)
 		if err != nil {
 			return err
 		}
@@ -177,79 +250,181 @@ func (s *SyncService) processData(tempTableName string) error {
 
 		offset := 0
 		for offset < totalCount {
+			// Аварийная остановка (отмена ctx) прерывает и выполняющийся запрос пачки
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("sync aborted: %w", err)
+			}
 			s.logger.Debug(fmt.Sprintf("Processing offset: %d", offset))
 			// 1. Получаем пачку из исходной таблицы
-			batch, err := s.source.GetBatch(
-				s.config.Source.Table,
-				offset,
-				batchSize,
-				s.sourceSchema,
-			)
+			var batch []domain.Record
+			err := s.batchRetry().Do(ctx, func() (err error) {
+				batch, err = s.source.GetBatch(
+					ctx,
+					s.config.Source.Table,
+					offset,
+					batchSize,
+					s.sourceSchema,
+				)
+				return err
+			}, s.logRetry("read batch"))
 			if err != nil {
 				return err
 			}
+			if len(batch) == 0 {
+				break
+			}
+			// Смещение считаем по прочитанным строкам: процессор может отбросить часть записей
+			offset += len(batch)
 
 			// 2. Обрабтываем данные(маппинг между таблицами если схемы разные)
-			s.processor.Process(batch)
+			s.processor.Process(ctx, batch)
 
-			// 3. Получаем batch если размер разный
-			processedBatch := s.processor.GetBatch(batchSize)
-			if len(processedBatch) == 0 {
-				break
+			if err := s.processor.Err(); err != nil {
+				return fmt.Errorf("process records failed: %w", err)
 			}
-			s.logger.Debug(fmt.Sprintf("Processed batch size: %d", len(processedBatch)))
 
-			// Для последней пачки
-			if batchSize > len(processedBatch) {
-				batchSize = len(processedBatch)
-			}
+			// 3. Забираем все обработанные записи
+			processedBatch := s.processor.GetBatch(s.processor.BufferSize())
+			s.logger.Debug(fmt.Sprintf("Processed batch size: %d", len(processedBatch)))
 
 			// 4. Вставляем в нужную временнную табличку
-			if err := s.target.InsertBatch(
-				tempTableName,
-				processedBatch,
-				s.processor.GetTargetColumns(),
-			); err != nil {
-				return fmt.Errorf("insert batch failed: %w", err)
+			if len(processedBatch) > 0 {
+				if err := s.insertBatch(ctx, tempTableName, processedBatch); err != nil {
+					return err
+				}
 			}
 
-			offset += batchSize
 			s.logger.Info(fmt.Sprintf("Progress: %d/%d records processed", offset, totalCount))
 		}
 	}
+
+	if err := s.processor.Err(); err != nil {
+		return fmt.Errorf("process records failed: %w", err)
+	}
+	if skipped := s.processor.SkippedCount(); skipped > 0 {
+		s.logger.Info(fmt.Sprintf("Skipped %d rows with oversized LOB values", skipped))
+	}
+	if rejected := s.processor.RejectedCount(); rejected > 0 {
+		s.logger.Info(fmt.Sprintf("Rejected %d rows by lookups", rejected))
+	}
+	if s.rejects != nil {
+		if count := s.rejects.Count(); count > 0 {
+			s.logger.Error(fmt.Sprintf("Rejected %d rows that failed to insert", count))
+		}
+		if err := s.rejects.checkPercent(totalCount); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (s *SyncService) syncTables() error {
-	tempTableName := s.config.Target.Table + s.config.TempTableSuffix
+// insertBatch вставляет пачку. При ошибке и настроенных rejects пачка делится
+// пополам, пока не будут найдены строки, которые вставить невозможно
+func (s *SyncService) insertBatch(ctx context.Context, tableName string, records []domain.Record) error {
+	err := s.batchRetry().Do(ctx, func() error {
+		return s.target.InsertBatch(ctx, tableName, records, s.processor.GetTargetColumns())
+	}, s.logRetry("insert batch"))
+	if err == nil {
+		return nil
+	}
+	// Делить пачку имеет смысл только при ошибке данных: при обрыве соединения все
+	// строки оказались бы отброшены, а после ошибки COMMIT половины пачки могли бы задвоиться
+	if s.rejects == nil || ctx.Err() != nil || !connectors.IsDataError(err) {
+		return fmt.Errorf("insert batch failed: %w", err)
+	}
+
+	if len(records) == 1 {
+		s.logger.Debug(fmt.Sprintf("Row rejected: %v", err))
+		return s.rejects.reject(ctx, records[0], err)
+	}
+
+	mid := len(records) / 2
+	if err := s.insertBatch(ctx, tableName, records[:mid]); err != nil {
+		return err
+	}
+	return s.insertBatch(ctx, tableName, records[mid:])
+}
+
+func (s *SyncService) syncTables(ctx context.Context) error {
+	tempTableName, activeTable, err := s.stagingTable()
+	if err != nil {
+		return err
+	}
+	s.processor.ResetStats()
+	if s.rejects != nil {
+		s.rejects.reset()
+	}
+
+	// 0. Загружаем справочники для обогащения
+	if err := s.processor.LoadLookups(ctx); err != nil {
+		return fmt.Errorf("load lookups failed: %w", err)
+	}
 	// 1. Создаем временную таблицы
-	err := s.target.CreateTempTable(
-		s.config.Target.Table,
+	err = s.target.CreateTempTable(
+		activeTable,
 		tempTableName,
-		s.processor.targetSchema)
+		s.stagingSchema())
 	if err != nil {
 		return fmt.Errorf("create temp table failed: %w", err)
 	}
 
 	// 2. Обрабатываем данные и записываем их в созданную табличку
-	if err := s.processData(tempTableName); err != nil {
+	if err := s.processData(ctx, tempTableName); err != nil {
 		if dropErr := s.target.DropTable(tempTableName); dropErr != nil {
 			s.logger.Error(fmt.Sprintf("failed to drop temp table after error: %v", dropErr))
 		}
 		return fmt.Errorf("data processing failed: %w", err)
 	}
 
-	// 3. Меняем таблицы местами (исходную и ту то что мы создали). Создаем бекап таблицы
-	if err := s.target.SwapTables(s.config.Target.Table, tempTableName); err != nil {
-		return fmt.Errorf("table swap failed: %w", err)
+	// Строим отложенные ключ и индексы после загрузки
+	if err := s.buildIndexes(ctx, tempTableName); err != nil {
+		if dropErr := s.target.DropTable(tempTableName); dropErr != nil {
+			s.logger.Error(fmt.Sprintf("failed to drop temp table after error: %v", dropErr))
+		}
+		return err
 	}
 
-	// 4. Удаляем временную табличку
-	if err := s.target.DropTable(tempTableName); err != nil {
-		s.logger.Error(fmt.Sprintf("failed to drop temp table: %v", err))
+	// 3. Проверяем качество данных. При нарушениях старая таблица остается на месте
+	if err := s.validate(ctx, tempTableName); err != nil {
+		if dropErr := s.target.DropTable(tempTableName); dropErr != nil {
+			s.logger.Error(fmt.Sprintf("failed to drop temp table after error: %v", dropErr))
+		}
+		return err
+	}
+
+	// 4. Сверяем источник и загруженные данные
+	if err := s.verify(ctx, tempTableName); err != nil {
+		if dropErr := s.target.DropTable(tempTableName); dropErr != nil {
+			s.logger.Error(fmt.Sprintf("failed to drop temp table after error: %v", dropErr))
+		}
+		return err
+	}
+
+	// Переносим ограничения, триггеры, комментарии и права прежней таблицы
+	if err := s.cloneMetadata(ctx, tempTableName, activeTable); err != nil {
+		if dropErr := s.target.DropTable(tempTableName); dropErr != nil {
+			s.logger.Error(fmt.Sprintf("failed to drop temp table after error: %v", dropErr))
+		}
+		return err
+	}
+
+	// При остановке приложения незавершенная загрузка не публикуется
+	if err := ctx.Err(); err != nil {
+		if dropErr := s.target.DropTable(tempTableName); dropErr != nil {
+			s.logger.Error(fmt.Sprintf("failed to drop temp table after error: %v", dropErr))
+		}
+		return fmt.Errorf("sync aborted before publish: %w", err)
+	}
+
+	// 5. Публикуем загруженную таблицу: замена таблиц или переключение представления/синонима
+	if err := s.publish(tempTableName, activeTable); err != nil {
+		return err
 	}
 
-	// 5. Выполняем процедуры если они добавлены
+	// 6. Удаляем резервные копии сверх настроек хранения
+	s.pruneBackups()
+
+	// 7. Выполняем процедуры если они добавлены
 	if len(s.config.PostProcedure) > 0 {
 		for i := 0; i < len(s.config.PostProcedure); i++ {
 			proc := s.config.PostProcedure[i]
@@ -263,21 +438,142 @@ func (s *SyncService) syncTables() error {
 	return nil
 }
 
-func (s *SyncService) Run() error {
-	if err := s.syncTables(); err != nil {
-		s.logger.Error(fmt.Sprintf("Sync failed: %v", err))
+// validate проверяет временную таблицу правилами качества данных
+func (s *SyncService) validate(ctx context.Context, tempTableName string) error {
+	if s.validator == nil {
+		return nil
+	}
+	failures, err := s.validator.Validate(ctx, tempTableName, s.config.Target.Table)
+	if err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if len(failures) == 0 {
+		s.logger.Info("Validation passed")
+		return nil
 	}
-	ticker := time.NewTicker(s.config.SyncInterval)
-	defer ticker.Stop()
 
-	for {
-		select {
-		case <-ticker.C:
-			s.logger.Info("sync start")
-			if err := s.syncTables(); err != nil {
-				s.logger.Error(fmt.Sprintf("Sync failed: %v", err))
-			}
-			s.logger.Info("sync end")
+	details := make([]string, len(failures))
+	for i, f := range failures {
+		s.logger.Error(fmt.Sprintf("Validation rule failed: %s", f))
+		details[i] = f.String()
+	}
+	return fmt.Errorf("validation failed, swap aborted: %s", strings.Join(details, "; "))
+}
+
+// pruneBackups удаляет старые резервные копии. Ошибка не прерывает синхронизацию
+func (s *SyncService) pruneBackups() {
+	var maxAge time.Duration
+	if s.config.Backup != nil {
+		maxAge = s.config.Backup.MaxAge
+	}
+	dropped, err := connectors.PruneBackups(s.target, s.config.Target.Table, s.config.Backup.KeepCount(), maxAge)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("failed to prune backups: %v", err))
+	}
+	for _, name := range dropped {
+		s.logger.Info(fmt.Sprintf("Backup table %s dropped", name))
+	}
+}
+
+// verify сравнивает количество строк и контрольные суммы источника и временной таблицы
+func (s *SyncService) verify(ctx context.Context, tempTableName string) error {
+	if s.verifier == nil {
+		return nil
+	}
+	cfg := s.verifier.cfg
+
+	source := checksumSide{conn: s.source, table: s.config.Source.Table}
+	target := checksumSide{conn: s.target, table: tempTableName, columns: cfg.Columns, key: cfg.KeyColumn}
+	if s.processor.HasPreloadedData() {
+		source.records = s.processor.PreloadedData()
+	}
+	for _, c := range cfg.Columns {
+		source.columns = append(source.columns, s.processor.SourceColumnFor(c))
+	}
+	if cfg.KeyColumn != "" {
+		source.key = s.processor.SourceColumnFor(cfg.KeyColumn)
+	}
+
+	// Если часть строк отброшена намеренно, суммы не совпадут - сверяем только количество
+	dropped := int64(s.processor.SkippedCount() + s.processor.RejectedCount())
+	if s.rejects != nil {
+		dropped += int64(s.rejects.Count())
+	}
+	if dropped > 0 {
+		sourceCount, targetCount, err := s.verifier.Counts(ctx, source, target)
+		if err != nil {
+			return fmt.Errorf("verification failed: %w", err)
+		}
+		if sourceCount-dropped != targetCount {
+			return fmt.Errorf("verification failed, swap aborted: source %d rows - %d dropped != target %d rows",
+				sourceCount, dropped, targetCount)
+		}
+		s.logger.Info(fmt.Sprintf("Verification passed: row counts match (%d rows dropped intentionally)", dropped))
+		return nil
+	}
+
+	diffs, err := s.verifier.Compare(ctx, source, target)
+	if err != nil {
+		return fmt.Errorf("verification failed: %w", err)
+	}
+	if len(diffs) == 0 {
+		s.logger.Info("Verification passed: row counts and checksums match")
+		return nil
+	}
+
+	details := make([]string, len(diffs))
+	for i, d := range diffs {
+		details[i] = s.verifier.describe(d)
+		s.logger.Error(fmt.Sprintf("Verification mismatch: %s", details[i]))
+	}
+	return fmt.Errorf("verification failed, swap aborted: %d key ranges differ: %s", len(diffs), strings.Join(details, "; "))
+}
+
+// SyncOnce выполняет одну синхронизацию. Используется планировщиком. При отмене ctx
+// загрузка прерывается после текущей пачки, временная таблица удаляется, а уже
+// начатая публикация доводится до конца
+func (s *SyncService) SyncOnce(ctx context.Context) error {
+	if lockCfg := s.config.Lock; lockCfg != nil && lockCfg.Distributed {
+		// Блокировка в целевой БД не дает другим экземплярам загружать ту же таблицу
+		lock, err := s.target.AcquireLock(s.config.Target.Table, lockCfg.WaitTimeout())
+		if errors.Is(err, connectors.ErrLockBusy) && lockCfg.Overlap != config.OverlapQueue {
+			// Таблицу загружает другой экземпляр: при overlap: skip это не ошибка
+			return fmt.Errorf("sync of %s: %w: %w", s.config.Target.Table, scheduler.ErrSkipped, err)
+		}
+		if err != nil {
+			return fmt.Errorf("sync of %s skipped: %w", s.config.Target.Table, err)
 		}
+		s.logger.Info(fmt.Sprintf("Lock %s acquired", lock.Name()))
+		defer func() {
+			if err := lock.Release(); err != nil {
+				s.logger.Error(fmt.Sprintf("Failed to release lock: %v", err))
+			}
+		}()
+	}
+
+	s.logger.Info("sync start")
+	defer s.logger.Info("sync end")
+	var jobRetry *config.RetryConfig
+	if s.config.Retry != nil {
+		jobRetry = s.config.Retry.Job
+	}
+	return connectors.NewRetryPolicy(jobRetry).Do(ctx, func() error {
+		return s.syncTables(ctx)
+	}, s.logRetry("sync"))
+}
+
+// batchRetry возвращает политику повтора чтения и вставки пачки
+func (s *SyncService) batchRetry() connectors.RetryPolicy {
+	if s.config.Retry == nil {
+		return connectors.NewRetryPolicy(nil)
+	}
+	return connectors.NewRetryPolicy(s.config.Retry.Batch)
+}
+
+// logRetry возвращает обработчик, который пишет повтор операции в лог
+func (s *SyncService) logRetry(operation string) func(attempt int, err error, wait time.Duration) {
+	return func(attempt int, err error, wait time.Duration) {
+		s.logger.Info(fmt.Sprintf("%s: attempt %d failed with %s error, retrying in %s: %v",
+			operation, attempt, connectors.Classify(err), wait.Round(time.Millisecond), err))
 	}
 }
@@ -0,0 +1,45 @@
+package sims_sync
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/domain"
+	"testing"
+)
+
+func TestProcessDataPreloadedKeepsLoadingAfterRejectedBatch(t *testing.T) {
+	ctx := context.Background()
+	dictionary := &fakeConnector{selectFn: func(string, []interface{}) ([]domain.Record, error) {
+		return []domain.Record{{"code": "A", "name": "Alpha"}}, nil
+	}}
+	lookup, err := NewLookup(dictionary, config.LookupConfig{
+		Name: "departments", Connection: "dict", Table: "departments",
+		KeyColumn: "code", ValueColumn: "name", SourceColumn: "department", OnMiss: LookupMissReject,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	processor := NewDataProcessor(0, WithLookup(lookup))
+	// Первая пачка целиком отбрасывается справочником
+	processor.SetSourceData([]domain.Record{
+		{"id": int64(1), "department": "X"},
+		{"id": int64(2), "department": "X"},
+		{"id": int64(3), "department": "A"},
+		{"id": int64(4), "department": "A"},
+	})
+	if err := processor.LoadLookups(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	target := &fakeConnector{}
+	s := newTestService(t, nil, target, processor, config.SyncConfig{BatchSize: 2})
+	if err := s.processData(ctx, "sims_temp"); err != nil {
+		t.Fatalf("processData: %v", err)
+	}
+	if len(target.inserted) != 2 {
+		t.Fatalf("inserted %d rows, want 2", len(target.inserted))
+	}
+	if got := processor.RejectedCount(); got != 2 {
+		t.Errorf("rejected %d rows, want 2", got)
+	}
+}
@@ -0,0 +1,88 @@
+package sims_sync
+
+import (
+	"db_swapper/internal/config"
+	"db_swapper/internal/domain"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// locationProvider реализуется коннекторами, которые знают пояс хранения дат
+type locationProvider interface {
+	Location() *time.Location
+}
+
+// timeZoneRules описывает перевод дат между поясами источника и цели
+type timeZoneRules struct {
+	sourceLoc *time.Location // Пояс подключения-источника
+	targetLoc *time.Location // Пояс подключения-цели
+	storeUTC  bool
+	columns   map[string]columnZones // Ключ - имя колонки в нижнем регистре
+}
+
+type columnZones struct {
+	source *time.Location
+	target *time.Location
+}
+
+// WithTimeZones включает нормализацию часовых поясов для колонок дата/время
+func WithTimeZones(sourceLoc, targetLoc *time.Location, storeUTC bool, zones []config.ColumnTimeZoneConfig) (ProcessorOption, error) {
+	rules := &timeZoneRules{
+		sourceLoc: sourceLoc,
+		targetLoc: targetLoc,
+		storeUTC:  storeUTC,
+		columns:   make(map[string]columnZones, len(zones)),
+	}
+	for _, z := range zones {
+		var cz columnZones
+		if z.SourceZone != "" {
+			loc, err := domain.LoadLocation(z.SourceZone)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", z.Column, err)
+			}
+			cz.source = loc
+		}
+		if z.TargetZone != "" {
+			loc, err := domain.LoadLocation(z.TargetZone)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", z.Column, err)
+			}
+			cz.target = loc
+		}
+		rules.columns[strings.ToLower(z.Column)] = cz
+	}
+
+	return func(p *DataProcessor) {
+		p.timeZones = rules
+	}, nil
+}
+
+// apply переводит значения дата/время записи по правилам.
+// Коннекторы отдают и принимают моменты времени, поэтому здесь достаточно
+// переопределить показания часов для колонок с особым поясом
+func (r *timeZoneRules) apply(record domain.Record) {
+	for name, value := range record {
+		t, ok := value.(time.Time)
+		if !ok {
+			continue
+		}
+		zones := r.columns[strings.ToLower(name)]
+
+		// Колонка хранится в источнике не в поясе подключения
+		if zones.source != nil {
+			t = domain.WallClockIn(t.In(r.sourceLoc), zones.source)
+		}
+
+		target := zones.target
+		if target == nil && r.storeUTC {
+			target = time.UTC
+		}
+		// Цель должна увидеть показания часов пояса target, а коннектор
+		// запишет их в поясе своего подключения
+		if target != nil {
+			t = domain.WallClockIn(t.In(target), r.targetLoc)
+		}
+		record[name] = t
+	}
+}
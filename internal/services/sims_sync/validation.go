@@ -0,0 +1,221 @@
+package sims_sync
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/domain"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// validationFailure - нарушенное правило качества данных
+type validationFailure struct {
+	Rule    string
+	Details string
+}
+
+func (f validationFailure) String() string {
+	return fmt.Sprintf("%s: %s", f.Rule, f.Details)
+}
+
+// validator проверяет данные во временной таблице перед публикацией.
+// Все проверки выполняются запросами на стороне БД
+type validator struct {
+	conn connectors.DatabaseConnector
+	cfg  config.ValidationConfig
+}
+
+func newValidator(conn connectors.DatabaseConnector, cfg config.ValidationConfig) *validator {
+	return &validator{conn: conn, cfg: cfg}
+}
+
+// Validate проверяет tempTable. originalTable используется для сравнения
+// количества строк с предыдущей версией
+func (v *validator) Validate(ctx context.Context, tempTable, originalTable string) ([]validationFailure, error) {
+	var failures []validationFailure
+
+	for _, col := range v.cfg.NotNull {
+		n, err := v.count(ctx, fmt.Sprintf("SELECT COUNT(*) AS cnt FROM %s WHERE %s IS NULL", tempTable, col))
+		if err != nil {
+			return nil, fmt.Errorf("not_null %s: %w", col, err)
+		}
+		if n > 0 {
+			failures = append(failures, validationFailure{"not_null " + col, fmt.Sprintf("%d rows with NULL", n)})
+		}
+	}
+
+	for _, cols := range v.cfg.Unique {
+		n, err := v.count(ctx, fmt.Sprintf(
+			"SELECT COUNT(*) AS cnt FROM (SELECT %s FROM %s GROUP BY %s HAVING COUNT(*) > 1) d",
+			cols, tempTable, cols))
+		if err != nil {
+			return nil, fmt.Errorf("unique %s: %w", cols, err)
+		}
+		if n > 0 {
+			failures = append(failures, validationFailure{"unique " + cols, fmt.Sprintf("%d duplicated keys", n)})
+		}
+	}
+
+	for _, rule := range v.cfg.Formats {
+		conds := v.formatConditions(rule)
+		if len(conds) == 0 {
+			continue
+		}
+		args := []interface{}{}
+		if rule.Regex != "" {
+			args = append(args, rule.Regex)
+		}
+		n, err := v.count(ctx, fmt.Sprintf("SELECT COUNT(*) AS cnt FROM %s WHERE %s IS NOT NULL AND (%s)",
+			tempTable, rule.Column, strings.Join(conds, " OR ")), args...)
+		if err != nil {
+			return nil, fmt.Errorf("format %s: %w", rule.Column, err)
+		}
+		if n > 0 {
+			failures = append(failures, validationFailure{"format " + rule.Column, fmt.Sprintf("%d rows with invalid format", n)})
+		}
+	}
+
+	for _, rule := range v.cfg.AllowedValues {
+		if len(rule.Values) == 0 {
+			continue
+		}
+		placeholders := make([]string, len(rule.Values))
+		args := make([]interface{}, len(rule.Values))
+		for i, val := range rule.Values {
+			placeholders[i] = connectors.Placeholder(v.conn, i+1)
+			args[i] = val
+		}
+		n, err := v.count(ctx, fmt.Sprintf("SELECT COUNT(*) AS cnt FROM %s WHERE %s IS NOT NULL AND %s NOT IN (%s)",
+			tempTable, rule.Column, rule.Column, strings.Join(placeholders, ",")), args...)
+		if err != nil {
+			return nil, fmt.Errorf("allowed_values %s: %w", rule.Column, err)
+		}
+		if n > 0 {
+			failures = append(failures, validationFailure{"allowed_values " + rule.Column, fmt.Sprintf("%d rows with unexpected value", n)})
+		}
+	}
+
+	if rc := v.cfg.RowCount; rc != nil {
+		rowFailures, err := v.checkRowCount(ctx, *rc, tempTable, originalTable)
+		if err != nil {
+			return nil, err
+		}
+		failures = append(failures, rowFailures...)
+	}
+
+	return failures, nil
+}
+
+// formatConditions возвращает условия, истинные для строк с неверным форматом
+func (v *validator) formatConditions(rule config.FormatRule) []string {
+	var conds []string
+	if rule.Regex != "" {
+		if connectors.Dialect(v.conn) == connectors.DialectOracle {
+			conds = append(conds, fmt.Sprintf("NOT REGEXP_LIKE(%s, :1)", rule.Column))
+		} else {
+			conds = append(conds, fmt.Sprintf("%s NOT REGEXP ?", rule.Column))
+		}
+	}
+	if rule.MinLength > 0 {
+		conds = append(conds, fmt.Sprintf("LENGTH(%s) < %d", rule.Column, rule.MinLength))
+	}
+	if rule.MaxLength > 0 {
+		conds = append(conds, fmt.Sprintf("LENGTH(%s) > %d", rule.Column, rule.MaxLength))
+	}
+	return conds
+}
+
+// checkRowCount сравнивает число строк с абсолютными границами и с предыдущей версией таблицы
+func (v *validator) checkRowCount(ctx context.Context, rc config.RowCountRule, tempTable, originalTable string) ([]validationFailure, error) {
+	var failures []validationFailure
+
+	n, err := v.count(ctx, fmt.Sprintf("SELECT COUNT(*) AS cnt FROM %s", tempTable))
+	if err != nil {
+		return nil, fmt.Errorf("row_count: %w", err)
+	}
+	if rc.Min > 0 && n < rc.Min {
+		failures = append(failures, validationFailure{"row_count", fmt.Sprintf("%d rows, expected at least %d", n, rc.Min)})
+	}
+	if rc.Max > 0 && n > rc.Max {
+		failures = append(failures, validationFailure{"row_count", fmt.Sprintf("%d rows, expected at most %d", n, rc.Max)})
+	}
+
+	if rc.MinRatio <= 0 && rc.MaxRatio <= 0 {
+		return failures, nil
+	}
+	// Предыдущей версии может не быть (первый запуск) - тогда сравнивать не с чем.
+	// Ошибка чтения существующей таблицы не должна отключать проверку
+	exists, err := v.tableExists(originalTable)
+	if err != nil {
+		return nil, fmt.Errorf("row_count: %w", err)
+	}
+	if !exists {
+		return failures, nil
+	}
+	prev, err := v.count(ctx, fmt.Sprintf("SELECT COUNT(*) AS cnt FROM %s", originalTable))
+	if err != nil {
+		return nil, fmt.Errorf("row_count: previous version: %w", err)
+	}
+	if prev == 0 {
+		return failures, nil
+	}
+	ratio := float64(n) / float64(prev)
+	if rc.MinRatio > 0 && ratio < rc.MinRatio {
+		failures = append(failures, validationFailure{"row_count", fmt.Sprintf("%d rows is %.2f of previous %d, expected at least %.2f", n, ratio, prev, rc.MinRatio)})
+	}
+	if rc.MaxRatio > 0 && ratio > rc.MaxRatio {
+		failures = append(failures, validationFailure{"row_count", fmt.Sprintf("%d rows is %.2f of previous %d, expected at most %.2f", n, ratio, prev, rc.MaxRatio)})
+	}
+	return failures, nil
+}
+
+// tableExists проверяет, что таблица цели уже существует
+func (v *validator) tableExists(table string) (bool, error) {
+	tables, err := v.conn.ListTables(table)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range tables {
+		if strings.EqualFold(name, table) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// count выполняет запрос, возвращающий одно число в колонке cnt
+func (v *validator) count(ctx context.Context, query string, args ...interface{}) (int64, error) {
+	return queryCount(ctx, v.conn, query, args...)
+}
+
+// queryCount выполняет запрос, возвращающий одно число в колонке cnt
+func queryCount(ctx context.Context, conn connectors.DatabaseConnector, query string, args ...interface{}) (int64, error) {
+	rows, err := conn.ExecuteSelect(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, fmt.Errorf("count query returned no rows")
+	}
+	return int64Value(fieldValue(rows[0], "cnt"))
+}
+
+// int64Value приводит числовое значение из записи к int64
+func int64Value(v interface{}) (int64, error) {
+	switch val := v.(type) {
+	case int64:
+		return val, nil
+	case float64:
+		return int64(val), nil
+	case domain.Decimal:
+		return strconv.ParseInt(val.String(), 10, 64)
+	case string:
+		return strconv.ParseInt(val, 10, 64)
+	case nil:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("unexpected count type %T", v)
+	}
+}
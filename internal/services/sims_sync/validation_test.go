@@ -0,0 +1,91 @@
+package sims_sync
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/domain"
+	"strings"
+	"testing"
+)
+
+// countResult - ответ на запрос COUNT(*), содержащий подстроку match
+type countResult struct {
+	match string
+	count int64
+}
+
+func TestValidationBlocksSwap(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.ValidationConfig
+		counts   []countResult // Проверяются по порядку, остальные запросы возвращают 0
+		previous bool          // Предыдущая версия таблицы существует
+		wantRule string        // Пусто - проверка пройдена и таблица публикуется
+	}{
+		{"passed", config.ValidationConfig{
+			NotNull:  []string{"imsi"},
+			Unique:   []string{"imsi"},
+			RowCount: &config.RowCountRule{Min: 2, MinRatio: 0.5},
+		}, []countResult{{"IS NULL", 0}, {"GROUP BY", 0}, {"FROM sims_temp", 4}, {"FROM sims", 5}}, true, ""},
+		{"not_null", config.ValidationConfig{NotNull: []string{"msisdn", "imsi"}},
+			[]countResult{{"imsi IS NULL", 3}}, false, "not_null imsi: 3 rows with NULL"},
+		{"unique", config.ValidationConfig{Unique: []string{"imsi,iccid"}},
+			[]countResult{{"GROUP BY imsi,iccid", 2}}, false, "unique imsi,iccid: 2 duplicated keys"},
+		{"format regex", config.ValidationConfig{Formats: []config.FormatRule{{Column: "imsi", Regex: "^[0-9]{15}$"}}},
+			[]countResult{{"imsi NOT REGEXP ?", 1}}, false, "format imsi: 1 rows with invalid format"},
+		{"format length", config.ValidationConfig{Formats: []config.FormatRule{{Column: "msisdn", MinLength: 11, MaxLength: 15}}},
+			[]countResult{{"LENGTH(msisdn) < 11 OR LENGTH(msisdn) > 15", 5}}, false, "format msisdn: 5 rows with invalid format"},
+		{"allowed_values", config.ValidationConfig{AllowedValues: []config.AllowedValuesRule{{Column: "status", Values: []string{"active", "blocked"}}}},
+			[]countResult{{"status NOT IN (?,?)", 4}}, false, "allowed_values status: 4 rows with unexpected value"},
+		{"row_count min", config.ValidationConfig{RowCount: &config.RowCountRule{Min: 100}},
+			[]countResult{{"FROM sims_temp", 10}}, false, "row_count: 10 rows, expected at least 100"},
+		{"row_count max", config.ValidationConfig{RowCount: &config.RowCountRule{Max: 100}},
+			[]countResult{{"FROM sims_temp", 101}}, false, "row_count: 101 rows, expected at most 100"},
+		{"row_count min_ratio", config.ValidationConfig{RowCount: &config.RowCountRule{MinRatio: 0.9}},
+			[]countResult{{"FROM sims_temp", 50}, {"FROM sims", 100}}, true, "expected at least 0.90"},
+		{"row_count max_ratio", config.ValidationConfig{RowCount: &config.RowCountRule{MaxRatio: 1.5}},
+			[]countResult{{"FROM sims_temp", 200}, {"FROM sims", 100}}, true, "expected at most 1.50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &fakeConnector{selectFn: func(query string, _ []interface{}) ([]domain.Record, error) {
+				for _, c := range tt.counts {
+					if strings.Contains(query, c.match) {
+						return []domain.Record{{"cnt": c.count}}, nil
+					}
+				}
+				return []domain.Record{{"cnt": int64(0)}}, nil
+			}}
+			if tt.previous {
+				target.tables = []string{"sims"}
+			}
+
+			cfg := config.SyncConfig{BatchSize: 10, TempTableSuffix: "_temp"}
+			cfg.Target.Table = "sims"
+			processor := NewDataProcessor(0)
+			processor.SetSourceData([]domain.Record{{"imsi": "250010000000001"}, {"imsi": "250010000000002"}})
+			s := newTestService(t, nil, target, processor, cfg)
+			s.validator = newValidator(target, tt.cfg)
+
+			err := s.syncTables(context.Background())
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Fatalf("syncTables: %v", err)
+				}
+				if len(target.swapped) != 1 {
+					t.Errorf("swapped %v, want the loaded table published", target.swapped)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "swap aborted") || !strings.Contains(err.Error(), tt.wantRule) {
+				t.Fatalf("syncTables = %v, want swap aborted by %q", err, tt.wantRule)
+			}
+			if len(target.swapped) > 0 {
+				t.Errorf("table swapped despite failed validation: %v", target.swapped)
+			}
+			if len(target.dropped) != 1 || target.dropped[0] != "sims_temp" {
+				t.Errorf("dropped %v, want the temp table dropped", target.dropped)
+			}
+		})
+	}
+}
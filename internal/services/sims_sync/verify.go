@@ -0,0 +1,330 @@
+package sims_sync
+
+import (
+	"context"
+	"db_swapper/internal/config"
+	"db_swapper/internal/connectors"
+	"db_swapper/internal/domain"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Режимы подсчета контрольной суммы
+const (
+	VerifyModeAuto     = "auto"     // pushdown, если источник и цель одного диалекта, иначе stream
+	VerifyModePushdown = "pushdown" // Агрегат считается запросом в БД
+	VerifyModeStream   = "stream"   // Строки читаются и хешируются в приложении
+)
+
+const (
+	defaultVerifyRangeSize = 100000
+	wholeTableBucket       = "*"
+	nullMarker             = "<NULL>"
+)
+
+// bucketSum - количество строк и контрольная сумма диапазона ключей
+type bucketSum struct {
+	count int64
+	hash  string
+}
+
+// checksumSide описывает одну сторону сравнения
+type checksumSide struct {
+	conn    connectors.DatabaseConnector
+	table   string   // Таблица (для источника-запроса не используется)
+	columns []string // Колонки контрольной суммы в терминах этой стороны
+	key     string   // Колонка ключа для диапазонов ("" - вся таблица одним диапазоном)
+	records []domain.Record
+}
+
+// rangeDiff - диапазон ключей, в котором данные расходятся
+type rangeDiff struct {
+	bucket string
+	source bucketSum
+	target bucketSum
+}
+
+// verifier сравнивает источник и временную таблицу после загрузки
+type verifier struct {
+	cfg       config.VerifyConfig
+	rangeSize int64
+}
+
+func newVerifier(cfg config.VerifyConfig) *verifier {
+	v := &verifier{cfg: cfg, rangeSize: cfg.RangeSize}
+	if v.rangeSize <= 0 {
+		v.rangeSize = defaultVerifyRangeSize
+	}
+	return v
+}
+
+// Compare считает суммы по диапазонам ключей на обеих сторонах и возвращает расхождения
+func (v *verifier) Compare(ctx context.Context, source, target checksumSide) ([]rangeDiff, error) {
+	diffs, _, err := v.compareRanges(ctx, source, target)
+	return diffs, err
+}
+
+// compareRanges возвращает расхождения и общее количество сравненных диапазонов
+func (v *verifier) compareRanges(ctx context.Context, source, target checksumSide) ([]rangeDiff, int, error) {
+	pushdown := v.usePushdown(source, target)
+
+	sourceSums, err := v.sums(ctx, source, pushdown)
+	if err != nil {
+		return nil, 0, fmt.Errorf("source checksum failed: %w", err)
+	}
+	targetSums, err := v.sums(ctx, target, pushdown)
+	if err != nil {
+		return nil, 0, fmt.Errorf("target checksum failed: %w", err)
+	}
+
+	buckets := make(map[string]struct{}, len(sourceSums))
+	for b := range sourceSums {
+		buckets[b] = struct{}{}
+	}
+	for b := range targetSums {
+		buckets[b] = struct{}{}
+	}
+
+	var diffs []rangeDiff
+	for b := range buckets {
+		s, t := sourceSums[b], targetSums[b]
+		if s != t {
+			diffs = append(diffs, rangeDiff{bucket: b, source: s, target: t})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return bucketLess(diffs[i].bucket, diffs[j].bucket) })
+	return diffs, len(buckets), nil
+}
+
+// Counts возвращает общее количество строк на обеих сторонах без контрольных сумм.
+// Количество не зависит от диалекта, поэтому каждая сторона считается своим COUNT(*)
+func (v *verifier) Counts(ctx context.Context, source, target checksumSide) (int64, int64, error) {
+	total := func(side checksumSide) (int64, error) {
+		if side.records != nil {
+			return int64(len(side.records)), nil
+		}
+		return queryCount(ctx, side.conn, fmt.Sprintf("SELECT COUNT(*) AS cnt FROM %s", side.table))
+	}
+
+	sourceCount, err := total(source)
+	if err != nil {
+		return 0, 0, fmt.Errorf("source count failed: %w", err)
+	}
+	targetCount, err := total(target)
+	if err != nil {
+		return 0, 0, fmt.Errorf("target count failed: %w", err)
+	}
+	return sourceCount, targetCount, nil
+}
+
+// usePushdown сообщает, можно ли считать суммы на стороне БД.
+// Хеш-функции диалектов различаются, поэтому pushdown возможен только для одного диалекта.
+// mode: pushdown для разных диалектов отклоняется раньше: в конфиге и в Diff
+func (v *verifier) usePushdown(source, target checksumSide) bool {
+	if source.records != nil || target.records != nil || v.cfg.Mode == VerifyModeStream {
+		return false
+	}
+	return connectors.Dialect(source.conn) == connectors.Dialect(target.conn)
+}
+
+func (v *verifier) sums(ctx context.Context, side checksumSide, pushdown bool) (map[string]bucketSum, error) {
+	if pushdown {
+		return v.pushdownSums(ctx, side)
+	}
+	return v.streamSums(ctx, side)
+}
+
+// pushdownSums считает суммы агрегатным запросом
+func (v *verifier) pushdownSums(ctx context.Context, side checksumSide) (map[string]bucketSum, error) {
+	bucketExpr := "0"
+	if side.key != "" {
+		bucketExpr = fmt.Sprintf("FLOOR(%s / %d)", side.key, v.rangeSize)
+	}
+
+	hashExpr := "0"
+	if len(side.columns) > 0 {
+		parts := make([]string, len(side.columns))
+		if connectors.Dialect(side.conn) == connectors.DialectOracle {
+			for i, c := range side.columns {
+				parts[i] = fmt.Sprintf("NVL(TO_CHAR(%s), '%s')", c, nullMarker)
+			}
+			hashExpr = fmt.Sprintf("ORA_HASH(%s)", strings.Join(parts, " || '|' || "))
+		} else {
+			for i, c := range side.columns {
+				parts[i] = fmt.Sprintf("COALESCE(CAST(%s AS CHAR), '%s')", c, nullMarker)
+			}
+			hashExpr = fmt.Sprintf("CRC32(CONCAT_WS('|', %s))", strings.Join(parts, ", "))
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s AS bkt, COUNT(*) AS cnt, SUM(%s) AS h FROM %s GROUP BY %s",
+		bucketExpr, hashExpr, side.table, bucketExpr)
+	rows, err := side.conn.ExecuteSelect(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	sums := make(map[string]bucketSum, len(rows))
+	for _, row := range rows {
+		cnt, err := int64Value(fieldValue(row, "cnt"))
+		if err != nil {
+			return nil, err
+		}
+		// Строки с NULL в ключе попадают в общий диапазон, как и в streamSums
+		bucket := wholeTableBucket
+		if b := fieldValue(row, "bkt"); side.key != "" && b != nil {
+			bucket = valueString(b)
+		}
+		sums[bucket] = bucketSum{count: cnt, hash: canonicalValue(fieldValue(row, "h"))}
+	}
+	return sums, nil
+}
+
+// streamSums читает строки пачками и считает сумму хешей строк.
+// Сумма не зависит от порядка строк
+func (v *verifier) streamSums(ctx context.Context, side checksumSide) (map[string]bucketSum, error) {
+	type acc struct {
+		count int64
+		hash  uint64
+	}
+	accs := make(map[string]*acc)
+	add := func(record domain.Record) {
+		bucket := wholeTableBucket
+		if side.key != "" {
+			bucket = v.bucketOf(fieldValue(record, side.key))
+		}
+		a, ok := accs[bucket]
+		if !ok {
+			a = &acc{}
+			accs[bucket] = a
+		}
+		a.count++
+		a.hash += rowHash(record, side.columns)
+	}
+
+	if side.records != nil {
+		for _, r := range side.records {
+			add(r)
+		}
+	} else {
+		// Ключ в схему не передается: коннектор отбросил бы строки с NULL в ключе,
+		// а суммы должны учитывать все строки. Пачки упорядочены по всем читаемым
+		// колонкам (без колонок - по ROWID), строки с равными значениями взаимозаменяемы
+		schema := &domain.TableSchema{}
+		for _, c := range append([]string{side.key}, side.columns...) {
+			if c != "" && !containsFold(schema.Columns, c) {
+				schema.Columns = append(schema.Columns, domain.ColumnInfo{Name: c})
+			}
+		}
+		const pageSize = 10000
+		for offset := 0; ; {
+			batch, err := side.conn.GetBatch(ctx, side.table, offset, pageSize, schema)
+			if err != nil {
+				return nil, err
+			}
+			if len(batch) == 0 {
+				break
+			}
+			for _, r := range batch {
+				add(r)
+			}
+			offset += len(batch)
+		}
+	}
+
+	sums := make(map[string]bucketSum, len(accs))
+	for b, a := range accs {
+		sums[b] = bucketSum{count: a.count, hash: strconv.FormatUint(a.hash, 10)}
+	}
+	return sums, nil
+}
+
+// bucketOf возвращает номер диапазона для числового ключа
+func (v *verifier) bucketOf(key interface{}) string {
+	str := valueString(key)
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		str = str[:i]
+	}
+	n, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return wholeTableBucket
+	}
+	// Евклидово деление на положительное число совпадает с FLOOR
+	return n.Div(n, big.NewInt(v.rangeSize)).String()
+}
+
+// describe описывает диапазон ключей для отчета
+func (v *verifier) describe(d rangeDiff) string {
+	rangeText := "whole table"
+	if n, ok := new(big.Int).SetString(d.bucket, 10); ok {
+		from := new(big.Int).Mul(n, big.NewInt(v.rangeSize))
+		to := new(big.Int).Add(from, big.NewInt(v.rangeSize))
+		rangeText = fmt.Sprintf("key range [%s, %s)", from, to)
+	}
+	return fmt.Sprintf("%s: source %d rows, target %d rows, checksum match %t",
+		rangeText, d.source.count, d.target.count, d.source.hash == d.target.hash)
+}
+
+// rowHash - хеш канонического представления колонок строки
+func rowHash(record domain.Record, columns []string) uint64 {
+	h := fnv.New64a()
+	for i, c := range columns {
+		if i > 0 {
+			h.Write([]byte{'|'})
+		}
+		h.Write([]byte(canonicalValue(fieldValue(record, c))))
+	}
+	return h.Sum64()
+}
+
+// canonicalValue приводит значение к виду, одинаковому для обоих диалектов:
+// числа без лишних нулей, время в UTC. Строки сравниваются как есть: "00123"
+// в текстовой колонке (ICCID, IMSI) не равно "123"
+func canonicalValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return nullMarker
+	case domain.Decimal:
+		return val.String()
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		if d, err := domain.ParseDecimal(strconv.FormatFloat(val, 'f', -1, 64)); err == nil {
+			return d.String()
+		}
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case time.Time:
+		return val.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return hex.EncodeToString(val)
+	case string:
+		return val
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+func containsFold(columns []domain.ColumnInfo, name string) bool {
+	for _, c := range columns {
+		if strings.EqualFold(c.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// bucketLess упорядочивает диапазоны по числовому значению
+func bucketLess(a, b string) bool {
+	x, okA := new(big.Int).SetString(a, 10)
+	y, okB := new(big.Int).SetString(b, 10)
+	if okA && okB {
+		return x.Cmp(y) < 0
+	}
+	return a < b
+}
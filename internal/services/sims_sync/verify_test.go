@@ -0,0 +1,39 @@
+package sims_sync
+
+import (
+	"db_swapper/internal/domain"
+	"testing"
+	"time"
+)
+
+func TestCanonicalValue(t *testing.T) {
+	dec := func(s string) domain.Decimal {
+		d, err := domain.ParseDecimal(s)
+		if err != nil {
+			t.Fatalf("ParseDecimal(%q): %v", s, err)
+		}
+		return d
+	}
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		name string
+		a, b interface{}
+		same bool
+	}{
+		{"decimal and int", dec("123.00"), int64(123), true},
+		{"decimal and float", dec("1.50"), 1.5, true},
+		{"same instant in different zones", time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 15, 0, 0, 0, moscow), true},
+		{"leading zeros in text", "00123", "123", false},
+		{"text and number", "123", int64(123), true},
+		{"null and empty", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := canonicalValue(tt.a), canonicalValue(tt.b)
+			if (a == b) != tt.same {
+				t.Errorf("canonicalValue(%v) = %q, canonicalValue(%v) = %q, want same=%t", tt.a, a, tt.b, b, tt.same)
+			}
+		})
+	}
+}